@@ -0,0 +1,158 @@
+package cache
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"sync"
+
+	"github.com/ernestokarim/cb/config"
+)
+
+// Version of the format of the files, older ones are discarded.
+const version = 1
+
+var nameRe = regexp.MustCompile(`^[a-z0-9-]+$`)
+
+var (
+	caches      = map[string]*Cache{}
+	cachesMutex = &sync.Mutex{}
+)
+
+// Cache stores the state of the files checked by one operation of the app,
+// so each one knows what changed since the last time it looked at them.
+// It's persisted between runs in ~/.cb/cache, in a folder per project.
+type Cache struct {
+	name string
+
+	mutex  *sync.Mutex
+	loaded bool
+	dirty  bool
+	files  map[string]*entry
+}
+
+// data is the content of the cache files.
+type data struct {
+	Version int               `json:"version"`
+	Files   map[string]*entry `json:"files"`
+}
+
+// Register creates the cache of an operation. Each part of the app that
+// uses a cache registers its own one with a unique name when starting.
+func Register(name string) *Cache {
+	cachesMutex.Lock()
+	defer cachesMutex.Unlock()
+
+	if !nameRe.MatchString(name) {
+		panic("invalid cache name: " + name)
+	}
+	if caches[name] != nil {
+		panic("cache already registered: " + name)
+	}
+
+	c := &Cache{
+		name:  name,
+		mutex: &sync.Mutex{},
+		files: map[string]*entry{},
+	}
+	caches[name] = c
+	return c
+}
+
+// Save writes the changes of all the caches to disk.
+func Save() error {
+	cachesMutex.Lock()
+	names := []string{}
+	for name := range caches {
+		names = append(names, name)
+	}
+	cachesMutex.Unlock()
+	sort.Strings(names)
+
+	for _, name := range names {
+		if err := caches[name].Save(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Save writes the cache to disk if it has changes. Nothing is written in
+// dry-run mode, the files didn't really change for the next run.
+func (c *Cache) Save() error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if !c.dirty || *config.DryRun {
+		return nil
+	}
+
+	filename, err := c.filename()
+	if err != nil {
+		return err
+	}
+	content, err := json.Marshal(&data{Version: version, Files: c.files})
+	if err != nil {
+		return fmt.Errorf("marshal cache failed: %w", err)
+	}
+	if err := writeAtomic(filename, content); err != nil {
+		return err
+	}
+
+	c.dirty = false
+	return nil
+}
+
+// load reads the cache from disk the first time it's used. A missing or
+// broken file starts an empty cache. It should be called with the mutex held.
+func (c *Cache) load() error {
+	if c.loaded {
+		return nil
+	}
+	c.loaded = true
+
+	filename, err := c.filename()
+	if err != nil {
+		return err
+	}
+	content, err := ioutil.ReadFile(filename)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("read cache failed: %w", err)
+	}
+
+	d := &data{}
+	if err := json.Unmarshal(content, d); err != nil || d.Version != version || d.Files == nil {
+		return nil
+	}
+	c.files = d.Files
+	return nil
+}
+
+// filename returns the file of the cache for the current project.
+func (c *Cache) filename() (string, error) {
+	dir, err := projectDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, c.name+".json"), nil
+}
+
+// projectDir returns the folder of the caches of the current project.
+func projectDir() (string, error) {
+	wd, err := os.Getwd()
+	if err != nil {
+		return "", fmt.Errorf("cannot get working directory: %w", err)
+	}
+	h := sha1.Sum([]byte(wd))
+	project := hex.EncodeToString(h[:])[:16]
+	return filepath.Join(config.GetUserConfigsPath(), "cache", project), nil
+}
@@ -1,40 +1,161 @@
 package cache
 
 import (
+	"crypto/sha1"
+	"encoding/hex"
 	"fmt"
+	"io"
 	"os"
-	"time"
+	"sort"
 )
 
-// List of allowed keys, used to avoid crashings
+// entry is the state of a file the last time it was checked.
+type entry struct {
+	ModTime int64  `json:"mtime"`
+	Ctime   int64  `json:"ctime"`
+	Size    int64  `json:"size"`
+	Inode   uint64 `json:"inode"`
+	Hash    string `json:"hash"`
+}
+
+// Change is the state of a file compared to the last time it was checked.
+type Change int
+
+// Changes of a file.
 const (
-	KeyDeps  = "deps"
-	KeyWatch = "watch"
+	Unchanged Change = iota
+	Added
+	Changed
 )
 
-// A map indexed by the operational key (a unique key that each
-// part of the app that used this cache has) and then by path.
-var modificationCache = map[string]map[string]time.Time{}
-
 // Modified checks if path has been modified since the last time
 // it was scanned. It so, or if it's not present in the cache,
-// it returns true and stores the new time.
-func Modified(key, path string) (bool, error) {
+// it returns true and stores the new state.
+//
+// Files with the same times, size & inode are not read again. Otherwise
+// the content is hashed, so touching a file doesn't count as a change.
+// The change time catches the files rewritten keeping their mtime.
+func (c *Cache) Modified(path string) (bool, error) {
+	change, err := c.Compare(path)
+	return change != Unchanged, err
+}
+
+// Compare works like Modified, telling apart the files that were not
+// present in the cache from the modified ones.
+func (c *Cache) Compare(path string) (Change, error) {
+	change, _, err := c.check(path)
+	return change, err
+}
+
+// Hash returns the hash of the content of the file, reading it only if
+// it changed since the last time it was checked.
+func (c *Cache) Hash(path string) (string, error) {
+	_, e, err := c.check(path)
+	if err != nil {
+		return "", err
+	}
+	return e.Hash, nil
+}
+
+// Paths returns the files present in the cache, sorted.
+func (c *Cache) Paths() ([]string, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if err := c.load(); err != nil {
+		return nil, err
+	}
+
+	paths := []string{}
+	for path := range c.files {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return paths, nil
+}
+
+// Empty returns true if the cache has no files, e.g. because it was never
+// saved before.
+func (c *Cache) Empty() (bool, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if err := c.load(); err != nil {
+		return false, err
+	}
+	return len(c.files) == 0, nil
+}
+
+// Forget removes the file from the cache, e.g. because it was deleted.
+func (c *Cache) Forget(path string) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if err := c.load(); err != nil {
+		return err
+	}
+
+	if _, ok := c.files[path]; ok {
+		delete(c.files, path)
+		c.dirty = true
+	}
+	return nil
+}
+
+func (c *Cache) check(path string) (Change, *entry, error) {
 	info, err := os.Lstat(path)
 	if err != nil {
-		return false, fmt.Errorf("stat failed: %s", err)
+		return Unchanged, nil, fmt.Errorf("stat failed: %w", err)
 	}
+	current := statEntry(info)
 
-	c := modificationCache[key]
-	if c == nil {
-		modificationCache[key] = map[string]time.Time{}
-		c = modificationCache[key]
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if err := c.load(); err != nil {
+		return Unchanged, nil, err
+	}
+
+	old := c.files[path]
+	if old != nil && old.ModTime == current.ModTime && old.Ctime == current.Ctime &&
+		old.Size == current.Size && old.Inode == current.Inode {
+		return Unchanged, old, nil
+	}
+
+	if info.Mode().IsRegular() {
+		current.Hash, err = HashFile(path)
+		if err != nil {
+			return Unchanged, nil, err
+		}
+	}
+	c.files[path] = current
+	c.dirty = true
+
+	if old == nil {
+		return Added, current, nil
+	}
+	if !info.Mode().IsRegular() || old.Hash != current.Hash {
+		return Changed, current, nil
+	}
+	return Unchanged, current, nil
+}
+
+func statEntry(info os.FileInfo) *entry {
+	e := &entry{
+		ModTime: info.ModTime().UnixNano(),
+		Size:    info.Size(),
+	}
+	e.Ctime, e.Inode = statChange(info)
+	return e
+}
+
+// HashFile returns the hash of the content of a file.
+func HashFile(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("open file failed: %w", err)
 	}
+	defer f.Close()
 
-	modified := c[path]
-	if info.ModTime() != modified {
-		c[path] = info.ModTime()
-		return true, nil
+	h := sha1.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", fmt.Errorf("hash file failed: %w", err)
 	}
-	return false, nil
+	return hex.EncodeToString(h.Sum(nil)), nil
 }
@@ -0,0 +1,85 @@
+package cache
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestCompare(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cb-cache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	c := &Cache{name: "test", mutex: &sync.Mutex{}, loaded: true, files: map[string]*entry{}}
+	path := filepath.Join(dir, "file.txt")
+	mtime := time.Now().Add(-time.Hour).Truncate(time.Second)
+
+	write := func(content string) {
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	chtimes := func(mtime time.Time) {
+		if err := os.Chtimes(path, mtime, mtime); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tests := []struct {
+		name   string
+		change func()
+		want   Change
+	}{
+		{"new file", func() { write("foo"); chtimes(mtime) }, Added},
+		{"untouched", func() {}, Unchanged},
+		{"touched", func() { chtimes(mtime.Add(time.Minute)) }, Unchanged},
+		{"same size", func() { write("bar"); chtimes(mtime.Add(2 * time.Minute)) }, Changed},
+		{"bigger", func() { write("foobar") }, Changed},
+		{"same mtime", func() {
+			time.Sleep(10 * time.Millisecond)
+			info, err := os.Stat(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			write("barfoo")
+			chtimes(info.ModTime())
+		}, Changed},
+	}
+	for _, test := range tests {
+		test.change()
+		change, err := c.Compare(path)
+		if err != nil {
+			t.Fatalf("%s: Compare failed: %s", test.name, err)
+		}
+		if change != test.want {
+			t.Errorf("%s: Compare() = %v, want %v", test.name, change, test.want)
+		}
+	}
+
+	if !c.dirty {
+		t.Errorf("the cache should have changes to save")
+	}
+	if _, err := c.Compare(filepath.Join(dir, "missing")); err == nil {
+		t.Errorf("Compare should fail with a missing file")
+	}
+
+	// Folders are compared by their times
+	if change, err := c.Compare(dir); err != nil || change != Added {
+		t.Errorf("Compare(dir) = %v, %v, want %v", change, err, Added)
+	}
+	if change, err := c.Compare(dir); err != nil || change != Unchanged {
+		t.Errorf("Compare(dir) = %v, %v, want %v", change, err, Unchanged)
+	}
+
+	if err := c.Forget(path); err != nil {
+		t.Fatal(err)
+	}
+	if change, err := c.Compare(path); err != nil || change != Added {
+		t.Errorf("Compare() after Forget = %v, %v, want %v", change, err, Added)
+	}
+}
@@ -0,0 +1,16 @@
+//go:build darwin || freebsd || netbsd
+
+package cache
+
+import (
+	"os"
+	"syscall"
+)
+
+// statChange returns the change time & the inode of the file.
+func statChange(info os.FileInfo) (int64, uint64) {
+	if stat, ok := info.Sys().(*syscall.Stat_t); ok {
+		return stat.Ctimespec.Nano(), uint64(stat.Ino)
+	}
+	return 0, 0
+}
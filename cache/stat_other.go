@@ -0,0 +1,13 @@
+//go:build !linux && !openbsd && !dragonfly && !solaris && !darwin && !freebsd && !netbsd
+
+package cache
+
+import (
+	"os"
+)
+
+// statChange returns zero values in the systems without change times or
+// inodes; the modification time, the size & the hash are checked anyway.
+func statChange(info os.FileInfo) (int64, uint64) {
+	return 0, 0
+}
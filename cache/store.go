@@ -0,0 +1,249 @@
+package cache
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/ernestokarim/cb/config"
+)
+
+// Store keeps the outputs of the tasks indexed by their content, and the
+// list of outputs produced by each fingerprint of their inputs. It lives
+// next to the caches of the project:
+//
+//	store/objects/ab/cdef...    content of the files
+//	store/outputs/<fp>.json     files produced by a fingerprint
+//
+// Identical files produced by different runs are only stored once. The
+// outputs not used in a month, and the contents only they referenced,
+// are pruned at the end of each run; removing the store folder cleans
+// it completely.
+type Store struct {
+	dir string
+}
+
+// maxStoreAge is the time the outputs are kept in the store without being
+// used by any build.
+const maxStoreAge = 30 * 24 * time.Hour
+
+// Output is a file produced by a task.
+type Output struct {
+	Path string      `json:"path"`
+	Hash string      `json:"hash"`
+	Mode os.FileMode `json:"mode"`
+}
+
+// OpenStore returns the store of the current project.
+func OpenStore() (*Store, error) {
+	dir, err := projectDir()
+	if err != nil {
+		return nil, err
+	}
+	return &Store{dir: filepath.Join(dir, "store")}, nil
+}
+
+// Lookup returns the outputs saved for the fingerprint, or nil if there is
+// none or some of their contents are missing from the store.
+func (s *Store) Lookup(fingerprint string) ([]*Output, error) {
+	content, err := ioutil.ReadFile(s.outputsPath(fingerprint))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("read outputs failed: %w", err)
+	}
+
+	outputs := []*Output{}
+	if err := json.Unmarshal(content, &outputs); err != nil {
+		return nil, nil
+	}
+	for _, output := range outputs {
+		if _, err := os.Stat(s.objectPath(output.Hash)); err != nil {
+			return nil, nil
+		}
+	}
+
+	// Mark them as used, so they're not pruned
+	now := time.Now()
+	os.Chtimes(s.outputsPath(fingerprint), now, now)
+	return outputs, nil
+}
+
+// Save copies the files to the store and records them as the outputs of
+// the fingerprint.
+func (s *Store) Save(fingerprint string, paths []string) error {
+	outputs := []*Output{}
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return fmt.Errorf("stat output failed: %w", err)
+		}
+		hash, err := HashFile(path)
+		if err != nil {
+			return err
+		}
+		if err := s.put(path, hash); err != nil {
+			return err
+		}
+		outputs = append(outputs, &Output{Path: path, Hash: hash, Mode: info.Mode().Perm()})
+	}
+
+	content, err := json.Marshal(outputs)
+	if err != nil {
+		return fmt.Errorf("marshal outputs failed: %w", err)
+	}
+	return writeAtomic(s.outputsPath(fingerprint), content)
+}
+
+// Restore writes the content of a stored output in its path.
+func (s *Store) Restore(output *Output) error {
+	if err := os.MkdirAll(filepath.Dir(output.Path), 0755); err != nil {
+		return fmt.Errorf("cannot prepare the folders: %w", err)
+	}
+
+	src, err := os.Open(s.objectPath(output.Hash))
+	if err != nil {
+		return fmt.Errorf("open stored file failed: %w", err)
+	}
+	defer src.Close()
+
+	dest, err := os.OpenFile(output.Path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, output.Mode)
+	if err != nil {
+		return fmt.Errorf("cannot create output file: %w", err)
+	}
+	defer dest.Close()
+
+	if _, err := io.Copy(dest, src); err != nil {
+		return fmt.Errorf("restore output failed: %w", err)
+	}
+	return nil
+}
+
+// PruneStore removes the outputs of the store of the current project that
+// have not been used for a while. Nothing is removed in dry-run mode.
+func PruneStore() error {
+	if *config.DryRun {
+		return nil
+	}
+	s, err := OpenStore()
+	if err != nil {
+		return err
+	}
+	return s.Prune(time.Now().Add(-maxStoreAge))
+}
+
+// Prune removes the outputs last used before the time, and the contents
+// that none of the remaining ones reference. Contents written after it
+// are kept too, other runs could be saving their outputs.
+func (s *Store) Prune(before time.Time) error {
+	entries, err := ioutil.ReadDir(filepath.Join(s.dir, "outputs"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("read store failed: %w", err)
+	}
+
+	used := map[string]bool{}
+	for _, entry := range entries {
+		path := filepath.Join(s.dir, "outputs", entry.Name())
+		if entry.ModTime().Before(before) {
+			if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+				return fmt.Errorf("prune outputs failed: %w", err)
+			}
+			continue
+		}
+
+		content, err := ioutil.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		outputs := []*Output{}
+		if err := json.Unmarshal(content, &outputs); err != nil {
+			continue
+		}
+		for _, output := range outputs {
+			used[output.Hash] = true
+		}
+	}
+
+	fn := func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if info.IsDir() || !info.ModTime().Before(before) {
+			return nil
+		}
+		hash := filepath.Base(filepath.Dir(path)) + info.Name()
+		if used[hash] {
+			return nil
+		}
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+	if err := filepath.Walk(filepath.Join(s.dir, "objects"), fn); err != nil {
+		return fmt.Errorf("prune objects failed: %w", err)
+	}
+	return nil
+}
+
+// put copies the file to the store if its content is not there yet.
+func (s *Store) put(path, hash string) error {
+	object := s.objectPath(hash)
+	if _, err := os.Stat(object); err == nil {
+		return nil
+	}
+
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("read output failed: %w", err)
+	}
+	return writeAtomic(object, content)
+}
+
+func (s *Store) objectPath(hash string) string {
+	return filepath.Join(s.dir, "objects", hash[:2], hash[2:])
+}
+
+func (s *Store) outputsPath(fingerprint string) string {
+	return filepath.Join(s.dir, "outputs", fingerprint+".json")
+}
+
+// writeAtomic writes & renames the file to avoid leaving half of it if
+// the process is interrupted. Each writer uses its own temporary file, the
+// same file could be written by several tasks at once.
+func writeAtomic(filename string, content []byte) error {
+	dir := filepath.Dir(filename)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("cannot prepare cache folder: %w", err)
+	}
+	tmp, err := ioutil.TempFile(dir, filepath.Base(filename)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("write cache failed: %w", err)
+	}
+	_, err = tmp.Write(content)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(tmp.Name(), 0644)
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), filename)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("write cache failed: %w", err)
+	}
+	return nil
+}
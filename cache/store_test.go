@@ -0,0 +1,178 @@
+package cache
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cb-store")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	s := &Store{dir: filepath.Join(dir, "store")}
+	a, b := filepath.Join(dir, "out/a.css"), filepath.Join(dir, "out/b.css")
+	if err := os.MkdirAll(filepath.Dir(a), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(a, []byte("a"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(b, []byte("b"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if outputs, err := s.Lookup("fp1"); err != nil || outputs != nil {
+		t.Errorf("Lookup(missing) = %v, %v, want nil", outputs, err)
+	}
+	if err := s.Save("fp1", []string{a, b}); err != nil {
+		t.Fatal(err)
+	}
+	outputs, err := s.Lookup("fp1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(outputs) != 2 || outputs[0].Path != a || outputs[1].Path != b || outputs[1].Mode != 0600 {
+		t.Fatalf("Lookup(fp1) = %+v", outputs)
+	}
+
+	// Restore the outputs after removing them
+	if err := os.RemoveAll(filepath.Dir(a)); err != nil {
+		t.Fatal(err)
+	}
+	for _, output := range outputs {
+		if err := s.Restore(output); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for path, want := range map[string]string{a: "a", b: "b"} {
+		content, err := ioutil.ReadFile(path)
+		if err != nil || string(content) != want {
+			t.Errorf("restored %s = %q, %v, want %q", path, content, err, want)
+		}
+	}
+
+	// Identical contents are stored once
+	if err := s.Save("fp2", []string{a}); err != nil {
+		t.Fatal(err)
+	}
+	objects := 0
+	filepath.Walk(filepath.Join(s.dir, "objects"), func(path string, info os.FileInfo, err error) error {
+		if err == nil && !info.IsDir() {
+			objects++
+		}
+		return err
+	})
+	if objects != 2 {
+		t.Errorf("the store has %d objects, want 2", objects)
+	}
+
+	// Missing contents invalidate the outputs
+	if err := os.Remove(s.objectPath(outputs[1].Hash)); err != nil {
+		t.Fatal(err)
+	}
+	if outputs, err := s.Lookup("fp1"); err != nil || outputs != nil {
+		t.Errorf("Lookup(fp1) without contents = %v, %v, want nil", outputs, err)
+	}
+	if outputs, err := s.Lookup("fp2"); err != nil || len(outputs) != 1 {
+		t.Errorf("Lookup(fp2) = %v, %v, want 1 output", outputs, err)
+	}
+}
+
+func TestWriteAtomic(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cb-store")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// Several tasks storing the same content at once
+	filename := filepath.Join(dir, "objects/ab/cdef")
+	var wg sync.WaitGroup
+	errs := make(chan error, 20)
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- writeAtomic(filename, []byte("content"))
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Errorf("writeAtomic failed: %s", err)
+		}
+	}
+
+	entries, err := ioutil.ReadDir(filepath.Dir(filename))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Name() != "cdef" {
+		t.Errorf("writeAtomic left temporary files: %v", entries)
+	}
+}
+
+func TestPrune(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cb-store")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	s := &Store{dir: filepath.Join(dir, "store")}
+	save := func(fp, content string) string {
+		path := filepath.Join(dir, fp+".css")
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := s.Save(fp, []string{path}); err != nil {
+			t.Fatal(err)
+		}
+		outputs, err := s.Lookup(fp)
+		if err != nil || len(outputs) != 1 {
+			t.Fatalf("Lookup(%s) = %v, %v", fp, outputs, err)
+		}
+		return outputs[0].Hash
+	}
+	old := time.Now().Add(-48 * time.Hour)
+	age := func(paths ...string) {
+		for _, path := range paths {
+			if err := os.Chtimes(path, old, old); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	unused := save("unused", "a")
+	shared := save("shared-old", "b")
+	save("shared-new", "b")
+	recent := save("recent", "c")
+	age(s.outputsPath("unused"), s.objectPath(unused), s.outputsPath("shared-old"), s.objectPath(shared))
+
+	if err := s.Prune(time.Now().Add(-24 * time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	for fp, kept := range map[string]bool{"unused": false, "shared-old": false, "shared-new": true, "recent": true} {
+		if _, err := os.Stat(s.outputsPath(fp)); (err == nil) != kept {
+			t.Errorf("outputs of %s kept = %v, want %v", fp, err == nil, kept)
+		}
+	}
+	for hash, kept := range map[string]bool{unused: false, shared: true, recent: true} {
+		if _, err := os.Stat(s.objectPath(hash)); (err == nil) != kept {
+			t.Errorf("object %s kept = %v, want %v", hash, err == nil, kept)
+		}
+	}
+
+	// Missing stores have nothing to prune
+	if err := (&Store{dir: filepath.Join(dir, "missing")}).Prune(time.Now()); err != nil {
+		t.Errorf("Prune of a missing store failed: %s", err)
+	}
+}
@@ -1,12 +1,13 @@
 package config
 
 import (
+	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"strconv"
 	"strings"
 
-	"github.com/ernestokarim/cb/colors"
 	"github.com/kylelemons/go-gypsy/yaml"
 )
 
@@ -15,28 +16,66 @@ var errNotFound = fmt.Errorf("not found")
 // Config wrapper to access settins.
 type Config struct {
 	f *yaml.File
+
+	// Path of the file, if it was loaded from disk, and the absolute
+	// folder it's in
+	path string
+	dir  string
+
+	// Files included by the config, and environment and sources
+	// merged over it
+	includes []string
+	env      string
+	overlays []string
+
+	// Paths of the values read from the user secrets
+	secrets map[string]bool
 }
 
 // NewConfig creates a new config wrapper from a YAML file. Used to load
 // specific config files (forms, validations, ...)
 func NewConfig(f *yaml.File) *Config {
-	return &Config{f}
+	return &Config{f: f}
 }
 
 // Load the basic config files for all task.
 // It first tries to load the config.yaml in the current directory
-// and then tries to load it from a "client" subfolder.
+// and then tries to load it from a "client" subfolder. It's merged over the
+// files it includes, the overlays of the environment selected with -env are
+// merged over it, and then the variables and secrets of the values are
+// resolved.
 func Load() (*Config, error) {
+	c, err := LoadRaw()
+	if c != nil {
+		if err := c.interpolate(); err != nil {
+			return nil, err
+		}
+	}
+	return c, err
+}
+
+// LoadRaw loads the config files like Load, but leaves the variables and
+// secrets of the values as they are. It's used to edit a config that cannot
+// be resolved yet.
+func LoadRaw() (*Config, error) {
 	c, err := tryLoad()
 	if c == nil && err == nil {
 		stat, statErr := os.Stat("client")
 		if statErr == nil && stat.IsDir() {
 			if pathErr := os.Chdir("client"); pathErr != nil {
-				return nil, fmt.Errorf("chdir to client folder failed: %s", err)
+				return nil, fmt.Errorf("chdir to client folder failed: %w", err)
 			}
 			c, err = tryLoad()
 		}
 	}
+	if c != nil {
+		if err := c.applyIncludes(); err != nil {
+			return nil, err
+		}
+		if err := c.applyEnv(*Env); err != nil {
+			return nil, err
+		}
+	}
 	return c, err
 }
 
@@ -45,122 +84,194 @@ func tryLoad() (*Config, error) {
 		if os.IsNotExist(err) {
 			return nil, nil
 		}
-		return nil, fmt.Errorf("stat config failed: %s", err)
+		return nil, fmt.Errorf("stat config failed: %w", err)
 	}
 	f, err := yaml.ReadFile("config.yaml")
 	if err != nil {
-		return nil, fmt.Errorf("read config failed: %s", err)
+		return nil, fmt.Errorf("read config failed: %w", err)
+	}
+
+	dir, err := os.Getwd()
+	if err != nil {
+		return nil, fmt.Errorf("get working directory failed: %w", err)
 	}
 
-	c := NewConfig(f)
+	c := &Config{f: f, path: "config.yaml", dir: dir}
 	return c, nil
 }
 
-// GetRequired returns a string from config or panic if it's not there.
-func (c *Config) GetRequired(format string, a ...interface{}) string {
-	s, err := c.f.Get(fmt.Sprintf(format, a...))
+// GetRequired returns a string from config or an error if it's not there.
+func (c *Config) GetRequired(format string, a ...interface{}) (string, error) {
+	spec := fmt.Sprintf(format, a...)
+	s, err := c.f.Get(spec)
 	if err != nil {
-		fmt.Printf("%srequired config element: %s%s\n", colors.Red, err, colors.Reset)
-		os.Exit(1)
-	}
-	if s[0] == '"' && s[len(s)-1] == '"' {
-		s = s[1 : len(s)-1]
+		if IsNotFound(err) {
+			return "", fmt.Errorf("required config element: %s", spec)
+		}
+		return "", fmt.Errorf("config element %s: %w", spec, err)
 	}
-	return s
+	return unquote(s), nil
 }
 
 // GetDefault returns a string from config or the default value if it's not there.
-func (c *Config) GetDefault(format, def string, a ...interface{}) string {
-	s, err := c.f.Get(fmt.Sprintf(format, a...))
+func (c *Config) GetDefault(format, def string, a ...interface{}) (string, error) {
+	spec := fmt.Sprintf(format, a...)
+	s, err := c.f.Get(spec)
 	if err != nil {
 		if IsNotFound(err) {
-			return def
+			return def, nil
 		}
-		fmt.Println(colors.Red, "required config element:", err, colors.Reset)
-		os.Exit(1)
-	}
-	if s[0] == '"' && s[len(s)-1] == '"' {
-		s = s[1 : len(s)-1]
+		return "", fmt.Errorf("config element %s: %w", spec, err)
 	}
-	return s
+	return unquote(s), nil
 }
 
 // GetInt returns an int from config or the default value if it's not there.
-func (c *Config) GetInt(format string, def int, a ...interface{}) int {
-	s := c.GetDefault(fmt.Sprintf(format, a...), fmt.Sprintf("%d", def))
-	n, err := strconv.ParseInt(s, 10, 64)
+func (c *Config) GetInt(format string, def int, a ...interface{}) (int, error) {
+	spec := fmt.Sprintf(format, a...)
+	s, err := c.GetDefault("%s", "", spec)
 	if err != nil {
-		return def
+		return 0, err
 	}
-	return int(n)
+	if s == "" {
+		return def, nil
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("config element %s should be an int: %s", spec, s)
+	}
+	return n, nil
 }
 
 // GetBoolDefault returns a boolean from config or the default value if it's
 // not there.
-func (c *Config) GetBoolDefault(format string, def bool, a ...interface{}) bool {
-	b, err := c.f.GetBool(fmt.Sprintf(format, a...))
+func (c *Config) GetBoolDefault(format string, def bool, a ...interface{}) (bool, error) {
+	spec := fmt.Sprintf(format, a...)
+	s, err := c.GetDefault("%s", "", spec)
 	if err != nil {
-		if IsNotFound(err) {
-			return def
-		}
-		panic(err)
+		return false, err
+	}
+	if s == "" {
+		return def, nil
 	}
-	return b
+	b, err := strconv.ParseBool(s)
+	if err != nil {
+		return false, fmt.Errorf("config element %s should be a bool: %s", spec, s)
+	}
+	return b, nil
 }
 
 // CountDefault returns the size of the list, or zero if it's not in the
 // config file.
-func (c *Config) CountDefault(format string, a ...interface{}) int {
-	cnt, err := c.f.Count(fmt.Sprintf(format, a...))
+func (c *Config) CountDefault(format string, a ...interface{}) (int, error) {
+	spec := fmt.Sprintf(format, a...)
+	cnt, err := c.f.Count(spec)
 	if err != nil {
 		if IsNotFound(err) {
-			return 0
+			return 0, nil
 		}
-		panic(err)
+		return 0, fmt.Errorf("config element %s should be a list: %w", spec, err)
 	}
-	return cnt
+	return cnt, nil
 }
 
-// CountRequired returns the size of the list, or panics if it's not there.
-func (c *Config) CountRequired(format string, a ...interface{}) int {
-	cnt, err := c.f.Count(fmt.Sprintf(format, a...))
+// CountRequired returns the size of the list, or an error if it's not there.
+func (c *Config) CountRequired(format string, a ...interface{}) (int, error) {
+	spec := fmt.Sprintf(format, a...)
+	cnt, err := c.f.Count(spec)
 	if err != nil {
-		panic(err)
+		if IsNotFound(err) {
+			return 0, fmt.Errorf("required config element: %s", spec)
+		}
+		return 0, fmt.Errorf("config element %s should be a list: %w", spec, err)
 	}
-	return cnt
+	return cnt, nil
 }
 
-// GetListRequired returns a list of strings from the config file panicing if
-// there is no list there.
-func (c *Config) GetListRequired(format string, a ...interface{}) []string {
+// GetListRequired returns a list of strings from the config file, or an
+// error if there is no list there.
+func (c *Config) GetListRequired(format string, a ...interface{}) ([]string, error) {
 	spec := fmt.Sprintf(format, a...)
-	items := []string{}
-	size := c.CountRequired(spec)
-	for i := 0; i < size; i++ {
-		items = append(items, c.GetRequired("%s[%d]", spec, i))
+	size, err := c.CountRequired("%s", spec)
+	if err != nil {
+		return nil, err
 	}
-	return items
+	return c.getList(spec, size)
 }
 
 // GetListDefault returns a list of strings from the config file or an empty
 // list if there are no results.
-func (c *Config) GetListDefault(format string, a ...interface{}) []string {
+func (c *Config) GetListDefault(format string, a ...interface{}) ([]string, error) {
 	spec := fmt.Sprintf(format, a...)
+	size, err := c.CountDefault("%s", spec)
+	if err != nil {
+		return nil, err
+	}
+	return c.getList(spec, size)
+}
+
+func (c *Config) getList(spec string, size int) ([]string, error) {
 	items := []string{}
-	size := c.CountDefault(spec)
 	for i := 0; i < size; i++ {
-		items = append(items, c.GetDefault("%s[%d]", "", spec, i))
+		item, err := c.GetRequired("%s[%d]", spec, i)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
 	}
-	return items
+	return items, nil
 }
 
-// Render is helper to render the config file to the output.
-func (c *Config) Render() {
+// Render is helper to render the config file to w. The values read
+// from the user secrets are masked.
+func (c *Config) Render(w io.Writer) {
 	if c.f.Root == nil {
-		fmt.Println(nil)
+		fmt.Fprintln(w, nil)
 		return
 	}
-	fmt.Println(yaml.Render(c.f.Root))
+	fmt.Fprintln(w, yaml.Render(masked(c.f.Root, "", c.secrets)))
+}
+
+// Filename returns the name of the file the config was loaded from.
+func (c *Config) Filename() string {
+	if c.path == "" {
+		return "config"
+	}
+	return c.path
+}
+
+// Dir returns the absolute folder of the config file, or an empty string
+// if it was not loaded from disk.
+func (c *Config) Dir() string {
+	return c.dir
+}
+
+// JSON serializes the whole config file. Scalar values are always encoded
+// as strings, the same way the getters read them.
+func (c *Config) JSON() ([]byte, error) {
+	return json.Marshal(nodeValue(c.f.Root))
+}
+
+func nodeValue(node yaml.Node) interface{} {
+	switch n := node.(type) {
+	case yaml.Map:
+		m := map[string]interface{}{}
+		for k, v := range n {
+			m[k] = nodeValue(v)
+		}
+		return m
+
+	case yaml.List:
+		l := []interface{}{}
+		for _, v := range n {
+			l = append(l, nodeValue(v))
+		}
+		return l
+
+	case yaml.Scalar:
+		return unquote(n.String())
+	}
+	return nil
 }
 
 // IsNotFound returns true if the error references a config key not found in
@@ -0,0 +1,180 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/kylelemons/go-gypsy/yaml"
+)
+
+// Decode fills v, usually a pointer to a struct or a slice of them, with the
+// config subtree found in the path (the whole file if it's empty). It returns
+// an error if the path is not in the file.
+//
+// Struct fields are matched with the key in their `config` tag, or with
+// their name starting in lowercase. Keys not present in the file leave the
+// field as it was, so defaults can be assigned before decoding; tag a field
+// as `config:"key,required"` to return an error instead. Supported values
+// are strings, ints, bools, and structs, slices and string maps of them.
+func (c *Config) Decode(v interface{}, format string, a ...interface{}) error {
+	spec := fmt.Sprintf(format, a...)
+
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return fmt.Errorf("decode config %s: a pointer is needed", spec)
+	}
+
+	node, err := yaml.Child(c.f.Root, spec)
+	if spec == "" && node == nil {
+		// Empty file
+		return nil
+	}
+	if err != nil || node == nil {
+		if err == nil || IsNotFound(err) {
+			return fmt.Errorf("required config element: %s", spec)
+		}
+		return fmt.Errorf("config element %s: %w", spec, err)
+	}
+
+	return decodeNode(node, rv.Elem(), spec)
+}
+
+func decodeNode(node yaml.Node, v reflect.Value, path string) error {
+	switch v.Kind() {
+	case reflect.String:
+		s, err := scalarValue(node, path)
+		if err != nil {
+			return err
+		}
+		v.SetString(s)
+
+	case reflect.Int, reflect.Int64:
+		s, err := scalarValue(node, path)
+		if err != nil {
+			return err
+		}
+		n, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return fmt.Errorf("config element %s should be an int: %s", path, s)
+		}
+		v.SetInt(n)
+
+	case reflect.Bool:
+		s, err := scalarValue(node, path)
+		if err != nil {
+			return err
+		}
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return fmt.Errorf("config element %s should be a bool: %s", path, s)
+		}
+		v.SetBool(b)
+
+	case reflect.Slice:
+		list, ok := node.(yaml.List)
+		if !ok {
+			return fmt.Errorf("config element %s should be a list", path)
+		}
+		items := reflect.MakeSlice(v.Type(), len(list), len(list))
+		for i, item := range list {
+			if err := decodeNode(item, items.Index(i), fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+		v.Set(items)
+
+	case reflect.Map:
+		m, ok := node.(yaml.Map)
+		if !ok {
+			return fmt.Errorf("config element %s should be a map", path)
+		}
+		if v.Type().Key().Kind() != reflect.String {
+			return fmt.Errorf("decode config %s: map keys should be strings", path)
+		}
+		values := reflect.MakeMap(v.Type())
+		for key, item := range m {
+			value := reflect.New(v.Type().Elem()).Elem()
+			if err := decodeNode(item, value, joinPath(path, key)); err != nil {
+				return err
+			}
+			values.SetMapIndex(reflect.ValueOf(key), value)
+		}
+		v.Set(values)
+
+	case reflect.Ptr:
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		return decodeNode(node, v.Elem(), path)
+
+	case reflect.Struct:
+		m, ok := node.(yaml.Map)
+		if !ok {
+			return fmt.Errorf("config element %s should be a map", path)
+		}
+		return decodeStruct(m, v, path)
+
+	default:
+		return fmt.Errorf("decode config %s: unsupported field type %s", path, v.Type())
+	}
+
+	return nil
+}
+
+func decodeStruct(m yaml.Map, v reflect.Value, path string) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+
+		key, required := fieldKey(field)
+		if key == "-" {
+			continue
+		}
+
+		fieldPath := joinPath(path, key)
+		node, ok := m[key]
+		if !ok || node == nil {
+			if required {
+				return fmt.Errorf("required config element: %s", fieldPath)
+			}
+			continue
+		}
+
+		if err := decodeNode(node, v.Field(i), fieldPath); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// fieldKey returns the config key of the struct field and if it's required.
+func fieldKey(field reflect.StructField) (string, bool) {
+	parts := strings.Split(field.Tag.Get("config"), ",")
+	key := parts[0]
+	if key == "" {
+		r := []rune(field.Name)
+		r[0] = unicode.ToLower(r[0])
+		key = string(r)
+	}
+	required := false
+	for _, opt := range parts[1:] {
+		if opt == "required" {
+			required = true
+		}
+	}
+	return key, required
+}
+
+func scalarValue(node yaml.Node, path string) (string, error) {
+	s, ok := node.(yaml.Scalar)
+	if !ok {
+		return "", fmt.Errorf("config element %s should be a single value", path)
+	}
+	return unquote(s.String()), nil
+}
@@ -0,0 +1,190 @@
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"regexp"
+	"strings"
+
+	"github.com/kylelemons/go-gypsy/yaml"
+)
+
+var plainValueRe = regexp.MustCompile(`^[a-zA-Z0-9_./~@+-][a-zA-Z0-9_./~@+:, -]*$`)
+
+// EditValue returns the content of the config file with the value of the
+// path changed, keeping the rest of the text (comments, order, format)
+// as it is. Missing keys of maps are added; lists can only change their
+// existing items. The loaded config is not modified.
+func (c *Config) EditValue(path, value string) (string, error) {
+	if c.path == "" {
+		return "", fmt.Errorf("the config was not loaded from a file")
+	}
+	content, err := ioutil.ReadFile(c.path)
+	if err != nil {
+		return "", fmt.Errorf("read config failed: %w", err)
+	}
+	return editValue(string(content), path, value)
+}
+
+// editValue changes the value and reads it back, to reject the values that
+// the YAML parser would read as something different.
+func editValue(content, path, value string) (string, error) {
+	edited, err := replaceValue(content, path, quoteValue(value))
+	if err != nil {
+		return "", err
+	}
+
+	f, err := yaml.Parse(strings.NewReader(edited))
+	if err != nil {
+		return "", fmt.Errorf("cannot set %s: %w", path, err)
+	}
+	got, err := (&yaml.File{Root: f}).Get(path)
+	got, _ = splitComment(got)
+	if err != nil || unquote(got) != value {
+		return "", fmt.Errorf("cannot set %s: the value cannot be written in the config file", path)
+	}
+	return edited, nil
+}
+
+func replaceValue(content, path, value string) (string, error) {
+	lines := strings.Split(content, "\n")
+	keys := scanKeys(content)
+
+	// Change the existing value
+	if pos, ok := keys[path]; ok {
+		line := lines[pos.line-1]
+		start := pos.col
+		if !strings.HasSuffix(path, "]") {
+			key, _, _ := splitKey(line[pos.col:])
+			start = pos.col + strings.Index(line[pos.col:], key) + len(key)
+			start += strings.Index(line[start:], ":") + 1
+		} else if _, _, ok := splitKey(line[pos.col:]); ok {
+			return "", fmt.Errorf("cannot set %s: it's not a single value", path)
+		}
+
+		old, comment := splitComment(line[start:])
+		if strings.HasPrefix(old, "|") || strings.HasPrefix(old, ">") || hasChildren(keys, path) {
+			return "", fmt.Errorf("cannot set %s: it's not a single value", path)
+		}
+		lines[pos.line-1] = strings.TrimRight(line[:start], " ") + " " + value + comment
+		return strings.Join(lines, "\n"), nil
+	}
+
+	// Look for the closest parent present in the file
+	parent := parentPath(path)
+	for parent != "" {
+		if _, ok := keys[parent]; ok {
+			break
+		}
+		parent = parentPath(parent)
+	}
+	missing := strings.Split(strings.TrimPrefix(strings.TrimPrefix(path, parent), "."), ".")
+	for _, key := range missing {
+		if strings.Contains(key, "[") || key == "" {
+			return "", fmt.Errorf("cannot set %s: only the keys of maps can be added", path)
+		}
+	}
+
+	// Place the new keys after the last line of the parent
+	at, col := len(lines), 0
+	if parent != "" {
+		pos := keys[parent]
+		text := lines[pos.line-1][pos.col:]
+		_, rest, isKey := splitKey(text)
+		if strings.HasSuffix(parent, "]") {
+			// Items of a list start their map in the same line
+			if v, _ := splitComment(text); !isKey && v != "" {
+				return "", fmt.Errorf("cannot set %s: %s is not a map", path, parent)
+			}
+			col = childCol(keys, parent, pos.col)
+		} else {
+			if v, _ := splitComment(rest); !isKey || v != "" {
+				return "", fmt.Errorf("cannot set %s: %s is not a map", path, parent)
+			}
+			col = childCol(keys, parent, pos.col+2)
+		}
+
+		at = pos.line
+		for i := pos.line; i < len(lines); i++ {
+			trimmed := strings.TrimSpace(lines[i])
+			if trimmed == "" || trimmed[0] == '#' {
+				continue
+			}
+			if len(lines[i])-len(strings.TrimLeft(lines[i], " ")) < col {
+				break
+			}
+			at = i + 1
+		}
+	} else {
+		for at > 0 && strings.TrimSpace(lines[at-1]) == "" {
+			at--
+		}
+	}
+
+	added := []string{}
+	for i, key := range missing {
+		line := strings.Repeat(" ", col+2*i) + key + ":"
+		if i == len(missing)-1 {
+			line += " " + value
+		}
+		added = append(added, line)
+	}
+	result := append(append(append([]string{}, lines[:at]...), added...), lines[at:]...)
+	if at == len(lines) {
+		result = append(result, "")
+	}
+	return strings.Join(result, "\n"), nil
+}
+
+// childCol returns the column of the keys inside the map of the path.
+func childCol(keys map[string]keyPos, path string, def int) int {
+	col, line := def, -1
+	for p, pos := range keys {
+		if parentPath(p) == path && strings.HasPrefix(p, path+".") {
+			if line == -1 || pos.line < line {
+				col, line = pos.col, pos.line
+			}
+		}
+	}
+	return col
+}
+
+func hasChildren(keys map[string]keyPos, path string) bool {
+	for p := range keys {
+		if strings.HasPrefix(p, path+".") || strings.HasPrefix(p, path+"[") {
+			return true
+		}
+	}
+	return false
+}
+
+// splitComment separates the value of a line from its trailing comment,
+// which keeps the spaces before it.
+func splitComment(s string) (string, string) {
+	quote := byte(0)
+	for i := 0; i < len(s); i++ {
+		switch {
+		case quote != 0:
+			if s[i] == quote {
+				quote = 0
+			}
+		case s[i] == '"' || s[i] == '\'':
+			quote = s[i]
+		case s[i] == '#' && (i == 0 || s[i-1] == ' ' || s[i-1] == '\t'):
+			value := strings.TrimRight(s[:i], " \t")
+			return strings.TrimSpace(value), s[len(value):]
+		}
+	}
+	return strings.TrimSpace(s), ""
+}
+
+// quoteValue writes the value between quotes if it could be read as
+// something else in the YAML file. The parser doesn't unescape the quoted
+// values, so the inner quotes are written as they are.
+func quoteValue(value string) string {
+	if plainValueRe.MatchString(value) && !strings.Contains(value, ": ") &&
+		!strings.HasSuffix(value, ":") && !strings.HasSuffix(value, " ") {
+		return value
+	}
+	return `"` + value + `"`
+}
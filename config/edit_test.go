@@ -0,0 +1,142 @@
+package config
+
+import "testing"
+
+func TestEditValue(t *testing.T) {
+	tests := []struct {
+		content string
+		path    string
+		value   string
+		want    string
+		fails   bool
+	}{
+		{
+			content: "push: a.com  # the host\nport: 80\n",
+			path:    "push",
+			value:   "b.com",
+			want:    "push: b.com  # the host\nport: 80\n",
+		},
+		{
+			content: "serve:\n  url: http://a/\n  port: 80\n",
+			path:    "serve.port",
+			value:   "8080",
+			want:    "serve:\n  url: http://a/\n  port: 8080\n",
+		},
+		{
+			content: "list:\n  - a\n  - b # second\n",
+			path:    "list[1]",
+			value:   "c",
+			want:    "list:\n  - a\n  - c # second\n",
+		},
+		{
+			content: "recess:\n  - source: a.less\n    dest: a.css\n",
+			path:    "recess[0].dest",
+			value:   "b.css",
+			want:    "recess:\n  - source: a.less\n    dest: b.css\n",
+		},
+		{
+			// Missing keys are added at the end of their parent
+			content: "serve:\n    url: http://a/\n\nother: 1\n",
+			path:    "serve.port",
+			value:   "80",
+			want:    "serve:\n    url: http://a/\n    port: 80\n\nother: 1\n",
+		},
+		{
+			content: "push: a.com\n\n",
+			path:    "deploy.ftp.host",
+			value:   "b.com",
+			want:    "push: a.com\ndeploy:\n  ftp:\n    host: b.com\n\n",
+		},
+		{
+			content: "push: a.com",
+			path:    "port",
+			value:   "80",
+			want:    "push: a.com\nport: 80\n",
+		},
+		{
+			content: "recess:\n  - source: a.less\n",
+			path:    "recess[0].dest",
+			value:   "a.css",
+			want:    "recess:\n  - source: a.less\n    dest: a.css\n",
+		},
+		{
+			content: "serve:\n  url: http://a/\n",
+			path:    "serve",
+			value:   "x",
+			fails:   true,
+		},
+		{
+			content: "text: |\n  a\n",
+			path:    "text",
+			value:   "x",
+			fails:   true,
+		},
+		{
+			content: "push: a.com\n",
+			path:    "push.host",
+			value:   "x",
+			fails:   true,
+		},
+		{
+			content: "list:\n  - a\n",
+			path:    "list[1]",
+			value:   "x",
+			fails:   true,
+		},
+		{
+			content: "recess:\n  - source: a.less\n",
+			path:    "recess[0]",
+			value:   "x",
+			fails:   true,
+		},
+		{
+			content: "push: a.com\n",
+			path:    "push",
+			value:   `say "hi": x`,
+			want:    "push: \"say \"hi\": x\"\n",
+		},
+		{
+			content: "push: a.com\n",
+			path:    "push",
+			value:   "two\nlines",
+			fails:   true,
+		},
+	}
+	for _, test := range tests {
+		got, err := editValue(test.content, test.path, test.value)
+		if test.fails {
+			if err == nil {
+				t.Errorf("editValue(%q, %s) should fail", test.content, test.path)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("editValue(%q, %s) failed: %s", test.content, test.path, err)
+			continue
+		}
+		if got != test.want {
+			t.Errorf("editValue(%q, %s) = %q, want %q", test.content, test.path, got, test.want)
+		}
+	}
+}
+
+func TestQuoteValue(t *testing.T) {
+	tests := []struct {
+		value, want string
+	}{
+		{"example.com", "example.com"},
+		{"http://localhost:8080/", "http://localhost:8080/"},
+		{"a, b", "a, b"},
+		{"", `""`},
+		{"key: value", `"key: value"`},
+		{"ends:", `"ends:"`},
+		{"# comment", `"# comment"`},
+		{`say "hi"`, `"say "hi""`},
+		{"trailing ", `"trailing "`},
+	}
+	for _, test := range tests {
+		if got := quoteValue(test.value); got != test.want {
+			t.Errorf("quoteValue(%q) = %s, want %s", test.value, got, test.want)
+		}
+	}
+}
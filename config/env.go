@@ -0,0 +1,205 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/kylelemons/go-gypsy/yaml"
+)
+
+// Environments adapt the config file to each place the app is built for.
+// Running with `-env staging` merges over config.yaml, in order:
+//
+//   - the `environments.staging` section of config.yaml
+//   - the config.staging.yaml file, next to config.yaml
+//
+// At least one of them should exist. The overlays are merged following
+// these rules:
+//
+//   - maps are merged key by key, recursively
+//   - scalars and lists replace the value of the base config
+//   - a key ending in `+` appends its list to the base one instead
+//
+// For example, to add a folder to the excluded ones in staging:
+//
+//	environments:
+//	  staging:
+//	    push: staging.example.com
+//	    deploy:
+//	      exclude+:
+//	        - tests
+//
+// The `environments` section itself is never part of the merged config.
+const environmentsKey = "environments"
+
+var envNameRe = regexp.MustCompile(`^[a-zA-Z0-9_-]+$`)
+
+// Env returns the name of the environment merged in the config, if any.
+func (c *Config) Env() string {
+	return c.env
+}
+
+// Overlays returns the names of the sources merged over the base file.
+func (c *Config) Overlays() []string {
+	return c.overlays
+}
+
+// applyEnv merges the overlays of the environment over the config.
+func (c *Config) applyEnv(env string) error {
+	root, ok := c.f.Root.(yaml.Map)
+	if !ok && c.f.Root != nil {
+		return fmt.Errorf("the config file should be a map")
+	}
+
+	var section yaml.Node
+	if root != nil {
+		section = root[environmentsKey]
+
+		// Remove the section from the effective config
+		merged := yaml.Map{}
+		for k, v := range root {
+			if k != environmentsKey {
+				merged[k] = v
+			}
+		}
+		c.f.Root = merged
+	}
+	if env == "" {
+		return nil
+	}
+
+	if !envNameRe.MatchString(env) {
+		return fmt.Errorf("invalid environment name: %s", env)
+	}
+	c.env = env
+
+	if section != nil {
+		envs, ok := section.(yaml.Map)
+		if !ok {
+			return fmt.Errorf("%s should be a map", environmentsKey)
+		}
+		if overlay := envs[env]; overlay != nil {
+			spec := environmentsKey + "." + env
+			if err := c.merge(overlay, spec); err != nil {
+				return err
+			}
+		}
+	}
+
+	filename := fmt.Sprintf("config.%s.yaml", env)
+	if _, err := os.Stat(filename); err != nil {
+		if !os.IsNotExist(err) {
+			return fmt.Errorf("stat overlay failed: %w", err)
+		}
+	} else {
+		f, err := yaml.ReadFile(filename)
+		if err != nil {
+			return fmt.Errorf("read overlay failed: %w", err)
+		}
+		if err := c.merge(f.Root, filename); err != nil {
+			return err
+		}
+	}
+
+	if len(c.overlays) == 0 {
+		return fmt.Errorf("environment not found: %s (add %s.%s or %s)",
+			env, environmentsKey, env, filename)
+	}
+	return nil
+}
+
+func (c *Config) merge(overlay yaml.Node, source string) error {
+	merged, err := mergeNodes(c.f.Root, overlay, "")
+	if err != nil {
+		return fmt.Errorf("merge %s failed: %w", source, err)
+	}
+	c.f.Root = merged
+	c.overlays = append(c.overlays, source)
+	return nil
+}
+
+// mergeNodes returns a new node with the overlay merged over the base one.
+// None of them are modified.
+func mergeNodes(base, overlay yaml.Node, path string) (yaml.Node, error) {
+	if overlay == nil {
+		return base, nil
+	}
+
+	om, ok := overlay.(yaml.Map)
+	if !ok {
+		return overlay, nil
+	}
+	bm, ok := base.(yaml.Map)
+	if !ok {
+		bm = yaml.Map{}
+	}
+
+	result := yaml.Map{}
+	for k, v := range bm {
+		result[k] = v
+	}
+	for k, v := range om {
+		if path == "" && k == environmentsKey {
+			// The overlays are applied later, keep their appends
+			result[k] = mergeLiteral(result[k], v)
+			continue
+		}
+		if strings.HasSuffix(k, "+") {
+			k = strings.TrimSuffix(k, "+")
+			list, err := appendLists(result[k], v, joinPath(path, k))
+			if err != nil {
+				return nil, err
+			}
+			result[k] = list
+			continue
+		}
+
+		node, err := mergeNodes(result[k], v, joinPath(path, k))
+		if err != nil {
+			return nil, err
+		}
+		result[k] = node
+	}
+	return result, nil
+}
+
+// mergeLiteral merges the maps like mergeNodes, but without applying the
+// appends of the keys ending in `+`.
+func mergeLiteral(base, overlay yaml.Node) yaml.Node {
+	om, ok := overlay.(yaml.Map)
+	if !ok {
+		return overlay
+	}
+	bm, ok := base.(yaml.Map)
+	if !ok {
+		return overlay
+	}
+
+	result := yaml.Map{}
+	for k, v := range bm {
+		result[k] = v
+	}
+	for k, v := range om {
+		result[k] = mergeLiteral(result[k], v)
+	}
+	return result
+}
+
+func appendLists(base, overlay yaml.Node, path string) (yaml.Node, error) {
+	items, ok := overlay.(yaml.List)
+	if !ok {
+		return nil, fmt.Errorf("%s+ should be a list", path)
+	}
+
+	list := yaml.List{}
+	if base != nil {
+		bl, ok := base.(yaml.List)
+		if !ok {
+			return nil, fmt.Errorf("%s should be a list to append items", path)
+		}
+		list = append(list, bl...)
+	}
+	return append(list, items...), nil
+}
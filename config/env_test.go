@@ -0,0 +1,168 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kylelemons/go-gypsy/yaml"
+)
+
+func parseNode(t *testing.T, content string) yaml.Node {
+	node, err := yaml.Parse(strings.NewReader(content))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return node
+}
+
+func TestMergeNodes(t *testing.T) {
+	tests := []struct {
+		base, overlay string
+		want          string
+		fails         bool
+	}{
+		{
+			base:    "a: 1\nb: 2\n",
+			overlay: "b: 3\nc: 4\n",
+			want:    "a: 1\nb: 3\nc: 4\n",
+		},
+		{
+			// Maps are merged recursively
+			base:    "m:\n  x: 1\n  n:\n    y: 2\n    z: 3\n",
+			overlay: "m:\n  n:\n    z: 4\n",
+			want:    "m:\n  x: 1\n  n:\n    y: 2\n    z: 4\n",
+		},
+		{
+			// Lists and scalars are replaced
+			base:    "l:\n  - a\n  - b\nm:\n  x: 1\n",
+			overlay: "l:\n  - c\nm: none\n",
+			want:    "l:\n  - c\nm: none\n",
+		},
+		{
+			base:    "m:\n  l:\n    - a\n",
+			overlay: "m:\n  l+:\n    - b\n    - c\n",
+			want:    "m:\n  l:\n    - a\n    - b\n    - c\n",
+		},
+		{
+			base:    "a: 1\n",
+			overlay: "l+:\n  - b\n",
+			want:    "a: 1\nl:\n  - b\n",
+		},
+		{
+			base:    "l: 1\n",
+			overlay: "l+:\n  - b\n",
+			fails:   true,
+		},
+		{
+			base:    "l:\n  - a\n",
+			overlay: "l+: b\n",
+			fails:   true,
+		},
+		{
+			// Appends inside the environments are kept for later
+			base:    "environments:\n  prod:\n    l+:\n      - a\n",
+			overlay: "environments:\n  prod:\n    l+:\n      - b\n    x: 1\n",
+			want:    "environments:\n  prod:\n    l+:\n      - b\n    x: 1\n",
+		},
+	}
+	for _, test := range tests {
+		got, err := mergeNodes(parseNode(t, test.base), parseNode(t, test.overlay), "")
+		if test.fails {
+			if err == nil {
+				t.Errorf("mergeNodes(%q, %q) should fail", test.base, test.overlay)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("mergeNodes(%q, %q) failed: %s", test.base, test.overlay, err)
+			continue
+		}
+		if got, want := yaml.Render(got), yaml.Render(parseNode(t, test.want)); got != want {
+			t.Errorf("mergeNodes(%q, %q) =\n%s\nwant\n%s", test.base, test.overlay, got, want)
+		}
+	}
+}
+
+func TestApplyEnv(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cb-config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	overlay := "push: file.example.com\nlist+:\n  - c\n"
+	if err := ioutil.WriteFile(filepath.Join(dir, "config.file.yaml"), []byte(overlay), 0600); err != nil {
+		t.Fatal(err)
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+
+	base := "push: example.com\nlist:\n  - a\nenvironments:\n" +
+		"  both:\n    push: both.example.com\n    list+:\n      - b\n" +
+		"  section:\n    push: section.example.com\n"
+	tests := []struct {
+		env      string
+		want     string
+		overlays []string
+		fails    bool
+	}{
+		{
+			env:  "",
+			want: "push: example.com\nlist:\n  - a\n",
+		},
+		{
+			env:      "section",
+			want:     "push: section.example.com\nlist:\n  - a\n",
+			overlays: []string{"environments.section"},
+		},
+		{
+			env:      "file",
+			want:     "push: file.example.com\nlist:\n  - a\n  - c\n",
+			overlays: []string{"config.file.yaml"},
+		},
+		{env: "missing", fails: true},
+		{env: "../file", fails: true},
+	}
+	for _, test := range tests {
+		c := NewConfig(&yaml.File{Root: parseNode(t, base)})
+		err := c.applyEnv(test.env)
+		if test.fails {
+			if err == nil {
+				t.Errorf("applyEnv(%q) should fail", test.env)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("applyEnv(%q) failed: %s", test.env, err)
+			continue
+		}
+		if got, want := yaml.Render(c.f.Root), yaml.Render(parseNode(t, test.want)); got != want {
+			t.Errorf("applyEnv(%q) =\n%s\nwant\n%s", test.env, got, want)
+		}
+		if strings.Join(c.Overlays(), ",") != strings.Join(test.overlays, ",") {
+			t.Errorf("applyEnv(%q) overlays = %v, want %v", test.env, c.Overlays(), test.overlays)
+		}
+	}
+
+	// Both overlays, the file one last
+	if err := ioutil.WriteFile(filepath.Join(dir, "config.both.yaml"), []byte(overlay), 0600); err != nil {
+		t.Fatal(err)
+	}
+	c := NewConfig(&yaml.File{Root: parseNode(t, base)})
+	if err := c.applyEnv("both"); err != nil {
+		t.Fatal(err)
+	}
+	want := "push: file.example.com\nlist:\n  - a\n  - b\n  - c\n"
+	if got := yaml.Render(c.f.Root); got != yaml.Render(parseNode(t, want)) {
+		t.Errorf("applyEnv(both) =\n%s\nwant\n%s", got, want)
+	}
+}
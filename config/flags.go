@@ -20,6 +20,27 @@ var (
 	// NoColors remove the colored output.
 	NoColors = flag.Bool("no-color", false, "don't use colors in the output")
 
+	// DryRun prints the tasks, commands & file changes without running them.
+	DryRun = flag.Bool("dry-run", false, "print the execution plan without changing anything")
+
+	// Jobs is the number of tasks that can run at the same time.
+	Jobs = flag.Int("j", 1, "number of tasks to run in parallel")
+
+	// KeepGoing runs the rest of the tasks when one of them fails.
+	KeepGoing = flag.Bool("k", false, "keep running the tasks that don't depend on a failed one")
+
+	// Format of the output: text, or json to emit one event per line.
+	Format = flag.String("format", "text", "output format: text, or json to emit one event per line")
+
+	// Profile is the file where the timings of the tasks will be written.
+	Profile = flag.String("profile", "", "write a trace of the tasks to that file (Chrome trace-event format)")
+
+	// Env is the environment whose overlays are merged over the config file.
+	Env = flag.String("env", "", "merge the overlays of this environment over config.yaml")
+
+	// Rebuild runs all the tasks, ignoring the outputs of previous builds.
+	Rebuild = flag.Bool("rebuild", false, "run all the tasks, ignoring the build cache")
+
 	// Port for the server tasks
 	Port = flag.Int("port", 9810, "server port")
 )
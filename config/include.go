@@ -0,0 +1,128 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/kylelemons/go-gypsy/yaml"
+)
+
+// The config file can include other files, to share a baseline between
+// projects. Paths are relative to the file that includes them, or to the
+// user configs folder if they start with `~/.cb/`:
+//
+//	include:
+//	  - ~/.cb/team.yaml
+//	  - ../shared/watch.yaml
+//
+// Included files are merged in order, each one over the previous ones, and
+// the file itself is merged over all of them with the same rules of the
+// environments: maps are merged, scalars and lists replaced, and keys
+// ending in `+` append to the included lists. Included files can include
+// other files too.
+const includeKey = "include"
+
+// Includes returns the files included by the config, in merge order.
+func (c *Config) Includes() []string {
+	return c.includes
+}
+
+// applyIncludes merges the config file over the files it includes.
+func (c *Config) applyIncludes() error {
+	root, err := c.resolveIncludes(c.f.Root, c.path, nil)
+	if err != nil {
+		return err
+	}
+	c.f.Root = root
+	return nil
+}
+
+func (c *Config) resolveIncludes(node yaml.Node, filename string, stack []string) (yaml.Node, error) {
+	if node == nil {
+		return nil, nil
+	}
+	root, ok := node.(yaml.Map)
+	if !ok {
+		return nil, fmt.Errorf("%s should be a map", filename)
+	}
+
+	abs, err := filepath.Abs(filename)
+	if err != nil {
+		return nil, fmt.Errorf("cannot get absolute path: %w", err)
+	}
+	for _, s := range stack {
+		if s == abs {
+			return nil, fmt.Errorf("include cycle: %s", strings.Join(append(stack, abs), " -> "))
+		}
+	}
+	stack = append(stack, abs)
+
+	includes, err := includePaths(root[includeKey], filename)
+	if err != nil {
+		return nil, err
+	}
+
+	var base yaml.Node
+	for _, include := range includes {
+		f, err := yaml.ReadFile(include)
+		if err != nil {
+			return nil, fmt.Errorf("read include failed (%s): %w", include, err)
+		}
+		included, err := c.resolveIncludes(f.Root, include, stack)
+		if err != nil {
+			return nil, err
+		}
+		if base, err = mergeNodes(base, included, ""); err != nil {
+			return nil, fmt.Errorf("merge %s failed: %w", include, err)
+		}
+		c.includes = append(c.includes, include)
+	}
+
+	own := yaml.Map{}
+	for k, v := range root {
+		if k != includeKey {
+			own[k] = v
+		}
+	}
+	merged, err := mergeNodes(base, own, "")
+	if err != nil {
+		return nil, fmt.Errorf("merge %s failed: %w", filename, err)
+	}
+	return merged, nil
+}
+
+// includePaths returns the files listed in the include key of a file.
+func includePaths(node yaml.Node, filename string) ([]string, error) {
+	var items []yaml.Node
+	switch n := node.(type) {
+	case nil:
+		return nil, nil
+	case yaml.Scalar:
+		items = []yaml.Node{n}
+	case yaml.List:
+		items = n
+	default:
+		return nil, fmt.Errorf("%s: %s should be a file or a list of them", filename, includeKey)
+	}
+
+	paths := []string{}
+	for _, item := range items {
+		scalar, ok := item.(yaml.Scalar)
+		if !ok {
+			return nil, fmt.Errorf("%s: %s should be a file or a list of them", filename, includeKey)
+		}
+		path := unquote(scalar.String())
+		if strings.HasPrefix(path, "~/.cb/") {
+			path = filepath.Join(GetUserConfigsPath(), strings.TrimPrefix(path, "~/.cb/"))
+		} else if !filepath.IsAbs(path) {
+			path = filepath.Join(filepath.Dir(filename), path)
+		}
+		if _, err := os.Stat(path); err != nil {
+			return nil, fmt.Errorf("%s: cannot include %s: %w", filename, path, err)
+		}
+		paths = append(paths, path)
+	}
+	return paths, nil
+}
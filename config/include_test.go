@@ -0,0 +1,104 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/kylelemons/go-gypsy/yaml"
+)
+
+func TestApplyIncludes(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cb-config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	old := userConfigsPath
+	userConfigsPath = filepath.Join(dir, "user")
+	defer func() { userConfigsPath = old }()
+
+	files := map[string]string{
+		"user/team.yaml":    "push: team.example.com\nexclude:\n  - a\n",
+		"shared/watch.yaml": "include: base.yaml\nwatch:\n  fast: 1\n",
+		"shared/base.yaml":  "watch:\n  fast: 0\n  slow: 0\n",
+		"cycle/a.yaml":      "include: b.yaml\n",
+		"cycle/b.yaml":      "include: a.yaml\n",
+		"env/env.yaml":      "environments:\n  prod:\n    exclude+:\n      - c\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		content  string
+		want     string
+		includes []string
+		fails    bool
+	}{
+		{
+			content: "push: example.com\n",
+			want:    "push: example.com\n",
+		},
+		{
+			// Paths of ~/.cb and relative to the file, included ones first
+			content:  "include:\n  - ~/.cb/team.yaml\n  - shared/watch.yaml\nexclude+:\n  - b\n",
+			want:     "push: team.example.com\nexclude:\n  - a\n  - b\nwatch:\n  fast: 1\n  slow: 0\n",
+			includes: []string{"user/team.yaml", "shared/base.yaml", "shared/watch.yaml"},
+		},
+		{
+			content:  "include: ~/.cb/team.yaml\npush: example.com\n",
+			want:     "push: example.com\nexclude:\n  - a\n",
+			includes: []string{"user/team.yaml"},
+		},
+		{
+			// The appends of the environments are kept for the overlays
+			content:  "include: env/env.yaml\nenvironments:\n  prod:\n    push: prod.example.com\n",
+			want:     "environments:\n  prod:\n    push: prod.example.com\n    exclude+:\n      - c\n",
+			includes: []string{"env/env.yaml"},
+		},
+		{content: "include: missing.yaml\n", fails: true},
+		{content: "include: cycle/a.yaml\n", fails: true},
+		{content: "include:\n  nested: a.yaml\n", fails: true},
+	}
+	for _, test := range tests {
+		c := &Config{
+			f:    &yaml.File{Root: parseNode(t, test.content)},
+			path: filepath.Join(dir, "config.yaml"),
+		}
+		err := c.applyIncludes()
+		if test.fails {
+			if err == nil {
+				t.Errorf("applyIncludes(%q) should fail", test.content)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("applyIncludes(%q) failed: %s", test.content, err)
+			continue
+		}
+		if got, want := yaml.Render(c.f.Root), yaml.Render(parseNode(t, test.want)); got != want {
+			t.Errorf("applyIncludes(%q) =\n%s\nwant\n%s", test.content, got, want)
+		}
+
+		includes := []string{}
+		for _, include := range c.Includes() {
+			includes = append(includes, filepath.ToSlash(strings.TrimPrefix(include, dir+string(filepath.Separator))))
+		}
+		if len(includes) > 0 || len(test.includes) > 0 {
+			if !reflect.DeepEqual(includes, test.includes) {
+				t.Errorf("applyIncludes(%q) includes = %v, want %v", test.content, includes, test.includes)
+			}
+		}
+	}
+}
@@ -0,0 +1,183 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/kylelemons/go-gypsy/yaml"
+)
+
+// Values of the config file can reference the environment of the process
+// and the secrets of the user, to avoid committing hosts and credentials:
+//
+//	push: ${FTP_HOST}
+//	serve:
+//	  url: ${SERVE_URL:-http://localhost:8080/}
+//	deploy:
+//	  token: secret:deploy.token
+//
+// `${VAR}` fails if the variable is not defined, and `${VAR:-default}` uses
+// the default if it's not defined or empty. Write `$${` to get a literal `${`.
+//
+// A value `secret:name` is replaced with the key name of ~/.cb/secrets.yaml.
+// That file should belong to the user and be readable only by them
+// (chmod 600). Secrets are masked when printing the config.
+const secretPrefix = "secret:"
+
+const secretsFile = "secrets.yaml"
+
+var varRe = regexp.MustCompile(`\$\$\{|\$\{([a-zA-Z_][a-zA-Z0-9_]*)(:-([^}]*))?\}`)
+
+type resolver struct {
+	secrets yaml.Node
+
+	// Paths of the resolved secrets
+	resolved map[string]bool
+}
+
+// interpolate resolves the variables and secrets of all the config values.
+func (c *Config) interpolate() error {
+	r := &resolver{resolved: map[string]bool{}}
+	root, err := r.node(c.f.Root, "")
+	if err != nil {
+		return err
+	}
+	c.f.Root = root
+	c.secrets = r.resolved
+	return nil
+}
+
+func (r *resolver) node(node yaml.Node, path string) (yaml.Node, error) {
+	switch n := node.(type) {
+	case yaml.Map:
+		m := yaml.Map{}
+		for k, v := range n {
+			value, err := r.node(v, joinPath(path, k))
+			if err != nil {
+				return nil, err
+			}
+			m[k] = value
+		}
+		return m, nil
+
+	case yaml.List:
+		l := yaml.List{}
+		for i, v := range n {
+			value, err := r.node(v, fmt.Sprintf("%s[%d]", path, i))
+			if err != nil {
+				return nil, err
+			}
+			l = append(l, value)
+		}
+		return l, nil
+
+	case yaml.Scalar:
+		return r.scalar(n.String(), path)
+	}
+	return node, nil
+}
+
+func (r *resolver) scalar(s, path string) (yaml.Node, error) {
+	if value := unquote(s); strings.HasPrefix(value, secretPrefix) {
+		secret, err := r.secret(strings.TrimPrefix(value, secretPrefix))
+		if err != nil {
+			return nil, fmt.Errorf("resolve %s failed: %w", path, err)
+		}
+		r.resolved[path] = true
+		return yaml.Scalar(secret), nil
+	}
+
+	var err error
+	s = varRe.ReplaceAllStringFunc(s, func(match string) string {
+		if match == "$${" {
+			return "${"
+		}
+		parts := varRe.FindStringSubmatch(match)
+		value, ok := os.LookupEnv(parts[1])
+		if parts[2] != "" {
+			if value == "" {
+				return parts[3]
+			}
+			return value
+		}
+		if !ok && err == nil {
+			err = fmt.Errorf("resolve %s failed: undefined variable %s", path, parts[1])
+		}
+		return value
+	})
+	if err != nil {
+		return nil, err
+	}
+	return yaml.Scalar(s), nil
+}
+
+func (r *resolver) secret(name string) (string, error) {
+	if r.secrets == nil {
+		secrets, err := readSecrets()
+		if err != nil {
+			return "", err
+		}
+		r.secrets = secrets
+	}
+
+	node, err := yaml.Child(r.secrets, name)
+	if err != nil || node == nil {
+		return "", fmt.Errorf("secret not found: %s", name)
+	}
+	scalar, ok := node.(yaml.Scalar)
+	if !ok {
+		return "", fmt.Errorf("secret %s should be a single value", name)
+	}
+	return unquote(scalar.String()), nil
+}
+
+func readSecrets() (yaml.Node, error) {
+	path := filepath.Join(GetUserConfigsPath(), secretsFile)
+	info, err := os.Stat(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("secrets file not found: ~/.cb/%s", secretsFile)
+		}
+		return nil, fmt.Errorf("stat secrets failed: %w", err)
+	}
+
+	if info.Mode().Perm()&0077 != 0 {
+		return nil, fmt.Errorf("~/.cb/%s should be readable only by its owner, "+
+			"run `chmod 600 ~/.cb/%s`", secretsFile, secretsFile)
+	}
+	if !ownedByUser(info) {
+		return nil, fmt.Errorf("~/.cb/%s should belong to the current user", secretsFile)
+	}
+
+	f, err := yaml.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read secrets failed: %w", err)
+	}
+	return f.Root, nil
+}
+
+// masked returns a copy of the node with the resolved secrets hidden.
+func masked(node yaml.Node, path string, secrets map[string]bool) yaml.Node {
+	if secrets[path] {
+		return yaml.Scalar("******")
+	}
+	switch n := node.(type) {
+	case yaml.Map:
+		m := yaml.Map{}
+		for k, v := range n {
+			m[k] = masked(v, joinPath(path, k), secrets)
+		}
+		return m
+
+	case yaml.List:
+		l := yaml.List{}
+		for i, v := range n {
+			l = append(l, masked(v, fmt.Sprintf("%s[%d]", path, i), secrets))
+		}
+		return l
+	}
+	return node
+}
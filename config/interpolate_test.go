@@ -0,0 +1,150 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/kylelemons/go-gypsy/yaml"
+)
+
+func TestInterpolate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cb-config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	old := userConfigsPath
+	userConfigsPath = dir
+	defer func() { userConfigsPath = old }()
+
+	secrets := "deploy:\n  token: abc123\n  nested:\n    a: 1\n"
+	if err := ioutil.WriteFile(filepath.Join(dir, secretsFile), []byte(secrets), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	os.Setenv("CB_TEST_HOST", "example.com")
+	os.Setenv("CB_TEST_EMPTY", "")
+	os.Unsetenv("CB_TEST_MISSING")
+	defer os.Unsetenv("CB_TEST_HOST")
+	defer os.Unsetenv("CB_TEST_EMPTY")
+
+	tests := []struct {
+		value  string
+		want   string
+		secret bool
+		fails  bool
+	}{
+		{value: "plain", want: "plain"},
+		{value: "${CB_TEST_HOST}", want: "example.com"},
+		{value: "http://${CB_TEST_HOST}:8080/", want: "http://example.com:8080/"},
+		{value: "${CB_TEST_EMPTY}", want: ""},
+		{value: "${CB_TEST_MISSING:-default}", want: "default"},
+		{value: "${CB_TEST_EMPTY:-default}", want: "default"},
+		{value: "${CB_TEST_HOST:-default}", want: "example.com"},
+		{value: "$${CB_TEST_HOST}", want: "${CB_TEST_HOST}"},
+		{value: "${CB_TEST_MISSING}", fails: true},
+		{value: "secret:deploy.token", want: "abc123", secret: true},
+		{value: `"secret:deploy.token"`, want: "abc123", secret: true},
+		{value: "secret:deploy.missing", fails: true},
+		{value: "secret:deploy.nested", fails: true},
+	}
+	for _, test := range tests {
+		c := NewConfig(&yaml.File{Root: yaml.Map{"key": yaml.Scalar(test.value)}})
+		err := c.interpolate()
+		if test.fails {
+			if err == nil {
+				t.Errorf("interpolate(%q) should fail", test.value)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("interpolate(%q) failed: %s", test.value, err)
+			continue
+		}
+		if got := c.f.Root.(yaml.Map)["key"].(yaml.Scalar).String(); got != test.want {
+			t.Errorf("interpolate(%q) = %q, want %q", test.value, got, test.want)
+		}
+		if c.secrets["key"] != test.secret {
+			t.Errorf("interpolate(%q) secret = %v, want %v", test.value, c.secrets["key"], test.secret)
+		}
+	}
+
+	// Secrets readable by others are rejected
+	if err := os.Chmod(filepath.Join(dir, secretsFile), 0644); err != nil {
+		t.Fatal(err)
+	}
+	c := NewConfig(&yaml.File{Root: yaml.Map{"key": yaml.Scalar("secret:deploy.token")}})
+	if err := c.interpolate(); err == nil {
+		t.Errorf("interpolate should fail with a secrets file readable by others")
+	}
+}
+
+func TestMasked(t *testing.T) {
+	node := yaml.Map{
+		"push": yaml.Scalar("example.com"),
+		"deploy": yaml.Map{
+			"token": yaml.Scalar("abc123"),
+			"hosts": yaml.List{yaml.Scalar("a"), yaml.Scalar("b")},
+		},
+	}
+	secrets := map[string]bool{"deploy.token": true, "deploy.hosts[1]": true}
+	want := "push: example.com\ndeploy:\n  token: ******\n  hosts:\n    - a\n    - ******\n"
+
+	if got := yaml.Render(masked(node, "", secrets)); got != yaml.Render(parseNode(t, want)) {
+		t.Errorf("masked() =\n%s\nwant\n%s", got, want)
+	}
+	if token := node["deploy"].(yaml.Map)["token"].(yaml.Scalar); token != "abc123" {
+		t.Errorf("masked() modified the original node")
+	}
+}
+
+func TestSecretSummary(t *testing.T) {
+	c := NewConfig(&yaml.File{Root: parseNode(t, "deploy:\n  token: abc123\n  host: example.com\n")})
+	c.secrets = map[string]bool{"deploy.token": true}
+
+	if value, _ := c.Summary("deploy.token"); value != "******" {
+		t.Errorf("Summary(deploy.token) = %q, want it masked", value)
+	}
+	if value, _ := c.SecretSummary("deploy.token"); value != "abc123" {
+		t.Errorf("SecretSummary(deploy.token) = %q, want abc123", value)
+	}
+	if value, _ := c.SecretSummary("deploy"); value != "{host: example.com, token: abc123}" {
+		t.Errorf("SecretSummary(deploy) = %q", value)
+	}
+}
+
+func TestLoadRaw(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cb-config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	content := []byte("push: ${CB_TEST_UNDEFINED}\n")
+	if err := ioutil.WriteFile(filepath.Join(dir, "config.yaml"), content, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+
+	if _, err := Load(); err == nil {
+		t.Errorf("Load() should fail with an undefined variable")
+	}
+	c, err := LoadRaw()
+	if err != nil {
+		t.Fatalf("LoadRaw() failed: %s", err)
+	}
+	if push, _ := c.GetDefault("push", ""); push != "${CB_TEST_UNDEFINED}" {
+		t.Errorf("LoadRaw() push = %q, want the variable as it is", push)
+	}
+}
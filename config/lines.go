@@ -0,0 +1,265 @@
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strings"
+)
+
+// keyLocation is the file and line where a key was set.
+type keyLocation struct {
+	file string
+	line int
+}
+
+// keyLines scans the config file to find the line of each key and list
+// item, indexed by its path (`recess[0].source`). The YAML parser doesn't
+// keep them, so problems found in the config would be hard to locate.
+// The included files and the overlays are scanned too, following the same
+// merge rules of the values, so each key points to the file that set it.
+func (c *Config) keyLines() map[string]keyLocation {
+	lines := map[string]keyLocation{}
+	if c.path == "" {
+		return lines
+	}
+
+	// The environment sections of all the files are merged literally,
+	// and then applied as a single overlay
+	section := map[string]keyLocation{}
+	envPrefix := environmentsKey + "." + c.env + "."
+	for _, file := range append(append([]string{}, c.includes...), c.path) {
+		own := map[string]keyLocation{}
+		env := map[string]keyLocation{}
+		for path, line := range scanFile(file) {
+			loc := keyLocation{file, line}
+			switch {
+			case c.env != "" && strings.HasPrefix(path, envPrefix):
+				env[strings.TrimPrefix(path, envPrefix)] = loc
+			case !isKeyOrUnder(path, includeKey) && !isKeyOrUnder(path, environmentsKey):
+				own[path] = loc
+			}
+		}
+		mergeLines(lines, own, true)
+		mergeLines(section, env, false)
+	}
+	mergeLines(lines, section, true)
+
+	for _, overlay := range c.overlays {
+		if strings.HasSuffix(overlay, ".yaml") {
+			own := map[string]keyLocation{}
+			for path, line := range scanFile(overlay) {
+				own[path] = keyLocation{overlay, line}
+			}
+			mergeLines(lines, own, true)
+		}
+	}
+	return lines
+}
+
+func scanFile(filename string) map[string]int {
+	content, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil
+	}
+	return scanKeyLines(string(content))
+}
+
+// mergeLines applies the locations of a file over the ones of the files
+// merged before it. Keys that replace the previous value remove the
+// locations of the old children; if appends is enabled, the items of the
+// keys ending in `+` are moved after the ones of the previous list.
+func mergeLines(base, overlay map[string]keyLocation, appends bool) {
+	paths := make([]string, 0, len(overlay))
+	for path := range overlay {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	offsets := map[string]int{}
+	for _, path := range paths {
+		loc := overlay[path]
+		if appends {
+			if i := appendIndex(path); i != -1 {
+				list, rest := path[:i], path[i+1:]
+				if rest == "" {
+					offsets[list] = countItems(base, list)
+					if _, ok := base[list]; !ok {
+						base[list] = loc
+					}
+					continue
+				}
+				var n int
+				if _, err := fmt.Sscanf(rest, "[%d]", &n); err != nil {
+					continue
+				}
+				rest = rest[strings.Index(rest, "]")+1:]
+				path = pathIndex(list, offsets[list]+n) + rest
+				base[path] = loc
+				continue
+			}
+		}
+
+		if !hasKeys(overlay, path) {
+			for p := range base {
+				if isUnder(p, path) {
+					delete(base, p)
+				}
+			}
+		}
+		base[path] = loc
+	}
+}
+
+// appendIndex returns the position of the `+` that ends a key of the path,
+// or -1 if there is none.
+func appendIndex(path string) int {
+	for i := 0; i < len(path); i++ {
+		if path[i] == '+' && (i == len(path)-1 || path[i+1] == '.' || path[i+1] == '[') {
+			return i
+		}
+	}
+	return -1
+}
+
+func countItems(lines map[string]keyLocation, list string) int {
+	n := 0
+	for {
+		if _, ok := lines[pathIndex(list, n)]; !ok {
+			return n
+		}
+		n++
+	}
+}
+
+// hasKeys returns true if the path is a map with keys inside.
+func hasKeys(lines map[string]keyLocation, path string) bool {
+	for p := range lines {
+		if strings.HasPrefix(p, path+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// isUnder returns true if the path is a child of the parent one.
+func isUnder(path, parent string) bool {
+	return strings.HasPrefix(path, parent+".") || strings.HasPrefix(path, parent+"[")
+}
+
+func isKeyOrUnder(path, key string) bool {
+	return path == key || isUnder(path, key)
+}
+
+// frame is an open key or list item while scanning the file.
+type frame struct {
+	col   int
+	path  string
+	item  bool
+	items int
+}
+
+func scanKeyLines(content string) map[string]int {
+	lines := map[string]int{}
+	for path, pos := range scanKeys(content) {
+		lines[path] = pos.line
+	}
+	return lines
+}
+
+// keyPos is the location of a key or list item in the file. The column is
+// the one of the key, or of the content of the item after the dash.
+type keyPos struct {
+	line, col int
+}
+
+func scanKeys(content string) map[string]keyPos {
+	lines := map[string]keyPos{}
+	stack := []*frame{}
+	blockCol := -1
+	for n, line := range strings.Split(content, "\n") {
+		trimmed := strings.TrimLeft(line, " ")
+		col := len(line) - len(trimmed)
+		trimmed = strings.TrimRight(trimmed, " \t\r")
+		if trimmed == "" || trimmed[0] == '#' {
+			continue
+		}
+
+		// Lines of a block scalar (| or >)
+		if blockCol != -1 {
+			if col > blockCol {
+				continue
+			}
+			blockCol = -1
+		}
+
+		// List items, possibly with a key inside (- key: value)
+		for trimmed == "-" || strings.HasPrefix(trimmed, "- ") {
+			for len(stack) > 0 && stack[len(stack)-1].col > col {
+				stack = stack[:len(stack)-1]
+			}
+			if len(stack) > 0 && stack[len(stack)-1].col == col && stack[len(stack)-1].item {
+				stack = stack[:len(stack)-1]
+			}
+			if len(stack) == 0 {
+				break
+			}
+
+			owner := stack[len(stack)-1]
+			item := &frame{
+				col:  col,
+				path: pathIndex(owner.path, owner.items),
+				item: true,
+			}
+			owner.items++
+			stack = append(stack, item)
+
+			rest := strings.TrimLeft(trimmed[1:], " ")
+			col += len(trimmed) - len(rest)
+			trimmed = rest
+			lines[item.path] = keyPos{n + 1, col}
+		}
+
+		key, value, ok := splitKey(trimmed)
+		if !ok {
+			continue
+		}
+		for len(stack) > 0 && stack[len(stack)-1].col >= col {
+			stack = stack[:len(stack)-1]
+		}
+		path := key
+		if len(stack) > 0 {
+			path = stack[len(stack)-1].path + "." + key
+		}
+		stack = append(stack, &frame{col: col, path: path})
+		lines[path] = keyPos{n + 1, col}
+
+		if strings.HasPrefix(value, "|") || strings.HasPrefix(value, ">") {
+			blockCol = col
+		}
+	}
+	return lines
+}
+
+// splitKey extracts the key and the value of a `key: value` line.
+func splitKey(s string) (string, string, bool) {
+	quote := byte(0)
+	for i := 0; i < len(s); i++ {
+		switch {
+		case quote != 0:
+			if s[i] == quote {
+				quote = 0
+			}
+		case s[i] == '"' || s[i] == '\'':
+			quote = s[i]
+		case s[i] == ':' && (i == len(s)-1 || s[i+1] == ' '):
+			key := strings.Trim(strings.TrimSpace(s[:i]), `"'`)
+			return key, strings.TrimSpace(s[i+1:]), true
+		}
+	}
+	return "", "", false
+}
+
+func pathIndex(path string, i int) string {
+	return fmt.Sprintf("%s[%d]", path, i)
+}
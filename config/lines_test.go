@@ -0,0 +1,163 @@
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestScanKeyLines(t *testing.T) {
+	tests := []struct {
+		content string
+		want    map[string]int
+	}{
+		{
+			"a: 1\nb:\n  c: 2\n  d: 3\n",
+			map[string]int{"a": 1, "b": 2, "b.c": 3, "b.d": 4},
+		},
+		{
+			"# comment\n\nlist:\n  - one\n  - two\n",
+			map[string]int{"list": 3, "list[0]": 4, "list[1]": 5},
+		},
+		{
+			"recess:\n  - source: a.less\n    dest: a.css\n  - source: b.less\n",
+			map[string]int{
+				"recess": 1, "recess[0]": 2, "recess[0].source": 2, "recess[0].dest": 3,
+				"recess[1]": 4, "recess[1].source": 4,
+			},
+		},
+		{
+			"text: |\n  key: not a key\n  more\nnext: 1\n",
+			map[string]int{"text": 1, "next": 4},
+		},
+		{
+			"url: \"http://a:b\"\n'quoted': x\n",
+			map[string]int{"url": 1, "quoted": 2},
+		},
+	}
+	for _, test := range tests {
+		if got := scanKeyLines(test.content); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("scanKeyLines(%q) = %v, want %v", test.content, got, test.want)
+		}
+	}
+}
+
+func TestMergeLines(t *testing.T) {
+	a := func(line int) keyLocation { return keyLocation{"a.yaml", line} }
+	b := func(line int) keyLocation { return keyLocation{"b.yaml", line} }
+	tests := []struct {
+		base, overlay map[string]keyLocation
+		appends       bool
+		want          map[string]keyLocation
+	}{
+		{
+			// Maps are merged
+			map[string]keyLocation{"m": a(1), "m.x": a(2), "m.y": a(3)},
+			map[string]keyLocation{"m": b(1), "m.y": b(2)},
+			true,
+			map[string]keyLocation{"m": b(1), "m.x": a(2), "m.y": b(2)},
+		},
+		{
+			// Lists and scalars replace the old children
+			map[string]keyLocation{"l": a(1), "l[0]": a(2), "l[1]": a(3), "l[1].k": a(3)},
+			map[string]keyLocation{"l": b(1), "l[0]": b(2)},
+			true,
+			map[string]keyLocation{"l": b(1), "l[0]": b(2)},
+		},
+		{
+			map[string]keyLocation{"m": a(1), "m.x": a(2)},
+			map[string]keyLocation{"m": b(1)},
+			true,
+			map[string]keyLocation{"m": b(1)},
+		},
+		{
+			// Appended items go after the old ones
+			map[string]keyLocation{"m": a(1), "m.l": a(2), "m.l[0]": a(3), "m.l[1]": a(4)},
+			map[string]keyLocation{"m": b(1), "m.l+": b(2), "m.l+[0]": b(3), "m.l+[1]": b(4)},
+			true,
+			map[string]keyLocation{
+				"m": b(1), "m.l": a(2), "m.l[0]": a(3), "m.l[1]": a(4),
+				"m.l[2]": b(3), "m.l[3]": b(4),
+			},
+		},
+		{
+			map[string]keyLocation{},
+			map[string]keyLocation{"l+": b(1), "l+[0]": b(2)},
+			true,
+			map[string]keyLocation{"l": b(1), "l[0]": b(2)},
+		},
+		{
+			// Literal merges keep the appends as they are
+			map[string]keyLocation{"l+": a(1), "l+[0]": a(2)},
+			map[string]keyLocation{"l+": b(1), "l+[0]": b(2)},
+			false,
+			map[string]keyLocation{"l+": b(1), "l+[0]": b(2)},
+		},
+	}
+	for _, test := range tests {
+		base := map[string]keyLocation{}
+		for k, v := range test.base {
+			base[k] = v
+		}
+		mergeLines(base, test.overlay, test.appends)
+		if !reflect.DeepEqual(base, test.want) {
+			t.Errorf("mergeLines(%v, %v) = %v, want %v", test.base, test.overlay, base, test.want)
+		}
+	}
+}
+
+func TestCheckLocations(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cb-config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	files := map[string]string{
+		"shared.yaml":      "shared:\n  unknown: 1\n",
+		"config.yaml":      "include: shared.yaml\nown: 1\nenvironments:\n  prod:\n    env: 1\n",
+		"config.prod.yaml": "overlay: 1\n",
+	}
+	for name, content := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+
+	c, err := tryLoad()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.applyIncludes(); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.applyEnv("prod"); err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]string{
+		"own":     "config.yaml:2",
+		"env":     "config.yaml:5",
+		"overlay": "config.prod.yaml:1",
+		"shared":  "shared.yaml:1",
+	}
+	got := map[string]string{}
+	for _, p := range c.Check(nil, nil) {
+		got[p.Path] = fmt.Sprintf("%s:%d", p.File, p.Line)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Check() locations = %v, want %v", got, want)
+	}
+}
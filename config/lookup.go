@@ -0,0 +1,126 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/kylelemons/go-gypsy/yaml"
+)
+
+// Lookup returns the value found in the path: single values as they are,
+// and lists and maps rendered as YAML. The secrets are masked.
+func (c *Config) Lookup(format string, a ...interface{}) (string, error) {
+	spec := fmt.Sprintf(format, a...)
+	node, err := yaml.Child(masked(c.f.Root, "", c.secrets), spec)
+	if err != nil || node == nil {
+		if err == nil || IsNotFound(err) {
+			return "", fmt.Errorf("config element not found: %s", spec)
+		}
+		return "", fmt.Errorf("config element %s: %w", spec, err)
+	}
+	if scalar, ok := node.(yaml.Scalar); ok {
+		return unquote(scalar.String()), nil
+	}
+	return yaml.Render(node), nil
+}
+
+// Summary returns the value of a declared key in a single line, to show it
+// next to the declaration: lists as `[a, b]` and maps as `{a: b}`. Paths
+// with `[]` list the values of all the items. The result is false if the
+// key is not present.
+func (c *Config) Summary(path string) (string, bool) {
+	return summary(masked(c.f.Root, "", c.secrets), path)
+}
+
+// SecretSummary works like Summary, without masking the secrets. It should
+// only be used for results that are never shown, e.g. to detect changes of
+// the values.
+func (c *Config) SecretSummary(path string) (string, bool) {
+	return summary(c.f.Root, path)
+}
+
+func summary(root yaml.Node, path string) (string, bool) {
+	values := []string{}
+	for _, p := range expandItems(root, path) {
+		node, err := yaml.Child(root, p)
+		if err != nil || node == nil {
+			continue
+		}
+		values = append(values, inline(node))
+	}
+	if len(values) == 0 {
+		return "", false
+	}
+	if strings.Contains(path, "[]") {
+		return "[" + strings.Join(values, ", ") + "]", true
+	}
+	return values[0], true
+}
+
+// expandItems replaces the `[]` of the path with the index of each item
+// present in the lists.
+func expandItems(root yaml.Node, path string) []string {
+	i := strings.Index(path, "[]")
+	if i == -1 {
+		return []string{path}
+	}
+
+	node, err := yaml.Child(root, path[:i])
+	if err != nil {
+		return nil
+	}
+	list, ok := node.(yaml.List)
+	if !ok {
+		return nil
+	}
+	paths := []string{}
+	for n := range list {
+		item := fmt.Sprintf("%s[%d]%s", path[:i], n, path[i+2:])
+		paths = append(paths, expandItems(root, item)...)
+	}
+	return paths
+}
+
+func inline(node yaml.Node) string {
+	switch n := node.(type) {
+	case yaml.Map:
+		keys := []string{}
+		for k := range n {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		items := []string{}
+		for _, k := range keys {
+			items = append(items, k+": "+inline(n[k]))
+		}
+		return "{" + strings.Join(items, ", ") + "}"
+
+	case yaml.List:
+		items := []string{}
+		for _, v := range n {
+			items = append(items, inline(v))
+		}
+		return "[" + strings.Join(items, ", ") + "]"
+
+	case yaml.Scalar:
+		return unquote(n.String())
+	}
+	return "~"
+}
+
+// Values returns the single values found in the path, expanding the `[]`
+// of the lists. Missing keys, lists & maps are ignored.
+func (c *Config) Values(path string) []string {
+	values := []string{}
+	for _, p := range expandItems(c.f.Root, path) {
+		node, err := yaml.Child(c.f.Root, p)
+		if err != nil {
+			continue
+		}
+		if scalar, ok := node.(yaml.Scalar); ok {
+			values = append(values, unquote(scalar.String()))
+		}
+	}
+	return values
+}
@@ -0,0 +1,14 @@
+//go:build !windows
+
+package config
+
+import (
+	"os"
+	"syscall"
+)
+
+// ownedByUser returns true if the file belongs to the current user.
+func ownedByUser(info os.FileInfo) bool {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	return !ok || int(stat.Uid) == os.Getuid()
+}
@@ -0,0 +1,280 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/kylelemons/go-gypsy/yaml"
+)
+
+// Key is the declaration of a config key used by a task. Items of a list
+// are written as `[]`: `recess[].source`.
+type Key struct {
+	Path     string
+	Kind     string
+	Required bool
+
+	// Value used by the task if the key is not present
+	Default string
+
+	spec string
+}
+
+// Kinds of values a key can declare.
+var kinds = map[string]bool{
+	"string": true,
+	"int":    true,
+	"bool":   true,
+	"list":   true,
+	"map":    true,
+}
+
+// ParseKey reads the declaration of a key in the same format as the
+// arguments of the tasks: `<path:kind>` if it's required and `[path:kind]`
+// if it's optional. The default kind is string. Optional keys can document
+// the value used when they're missing with `[path:kind=default]`.
+func ParseKey(spec string) (Key, error) {
+	key := Key{Path: spec, Kind: "string", spec: spec}
+	if len(spec) > 1 {
+		switch {
+		case spec[0] == '<' && spec[len(spec)-1] == '>':
+			key.Path = spec[1 : len(spec)-1]
+			key.Required = true
+		case spec[0] == '[' && spec[len(spec)-1] == ']':
+			key.Path = spec[1 : len(spec)-1]
+		}
+	}
+	if i := strings.Index(key.Path, "="); i != -1 {
+		if key.Required {
+			return Key{}, fmt.Errorf("required config key with a default value: %s", spec)
+		}
+		key.Path, key.Default = key.Path[:i], key.Path[i+1:]
+	}
+	if i := strings.LastIndex(key.Path, ":"); i != -1 {
+		key.Path, key.Kind = key.Path[:i], key.Path[i+1:]
+	}
+	if !kinds[key.Kind] {
+		return Key{}, fmt.Errorf("unknown kind of config key %s: %s", key.Path, key.Kind)
+	}
+	return key, nil
+}
+
+func (k Key) String() string {
+	return k.spec
+}
+
+// Problem is an error found validating the config file.
+type Problem struct {
+	File    string
+	Line    int
+	Path    string
+	Message string
+
+	// Warnings don't stop the tasks
+	Warning bool
+}
+
+func (p *Problem) String() string {
+	level := "error"
+	if p.Warning {
+		level = "warning"
+	}
+	if p.Line == 0 {
+		return fmt.Sprintf("%s: %s: %s: %s", p.File, level, p.Path, p.Message)
+	}
+	return fmt.Sprintf("%s:%d: %s: %s: %s", p.File, p.Line, level, p.Path, p.Message)
+}
+
+// Check validates the config file against the keys of all the tasks.
+// Keys not declared by anyone are reported as warnings; wrong types and
+// missing values of the required keys are errors. Only the keys in the
+// required list are checked for presence.
+func (c *Config) Check(known, required []Key) []*Problem {
+	lines := c.keyLines()
+	s := &schema{
+		known:  map[string]Key{},
+		lines:  lines,
+		file:   c.Filename(),
+		parent: map[string]bool{},
+	}
+	for _, k := range known {
+		s.known[k.Path] = k
+		for p := parentPath(k.Path); p != ""; p = parentPath(p) {
+			s.parent[p] = true
+		}
+	}
+
+	s.walk(c.f.Root, "")
+	for _, k := range required {
+		if k.Required {
+			s.checkPresent(c.f.Root, "", k)
+		}
+	}
+
+	sort.SliceStable(s.problems, func(i, j int) bool {
+		if s.problems[i].File != s.problems[j].File {
+			return s.problems[i].File < s.problems[j].File
+		}
+		return s.problems[i].Line < s.problems[j].Line
+	})
+	return s.problems
+}
+
+type schema struct {
+	known    map[string]Key
+	parent   map[string]bool
+	lines    map[string]keyLocation
+	file     string
+	problems []*Problem
+}
+
+func (s *schema) report(path string, warning bool, format string, a ...interface{}) {
+	loc := s.location(path)
+	s.problems = append(s.problems, &Problem{
+		File:    loc.file,
+		Line:    loc.line,
+		Path:    path,
+		Message: fmt.Sprintf(format, a...),
+		Warning: warning,
+	})
+}
+
+// location returns the place where the path was set, or the one of its
+// nearest parent. Paths not found are reported in the config file.
+func (s *schema) location(path string) keyLocation {
+	for ; path != ""; path = parentPath(path) {
+		if loc, ok := s.lines[path]; ok {
+			return loc
+		}
+	}
+	return keyLocation{file: s.file}
+}
+
+var indexRe = regexp.MustCompile(`\[\d+\]`)
+
+// walk checks the types of all the nodes of the file, reporting the ones
+// that nobody declares.
+func (s *schema) walk(node yaml.Node, path string) {
+	generic := indexRe.ReplaceAllString(path, "[]")
+	if path != "" {
+		key, ok := s.known[generic]
+		if !ok && !s.parent[generic] {
+			s.report(path, true, "unknown key")
+			return
+		}
+		if node == nil {
+			return
+		}
+		if ok && !s.checkKind(node, path, key) {
+			return
+		}
+		if ok && (key.Kind == "map" || key.Kind == "list") && !s.parent[generic] {
+			// Free-form content
+			return
+		}
+	}
+
+	switch n := node.(type) {
+	case yaml.Map:
+		for k, v := range n {
+			child := k
+			if path != "" {
+				child = path + "." + k
+			}
+			s.walk(v, child)
+		}
+	case yaml.List:
+		for i, v := range n {
+			s.walk(v, fmt.Sprintf("%s[%d]", path, i))
+		}
+	}
+}
+
+func (s *schema) checkKind(node yaml.Node, path string, key Key) bool {
+	switch key.Kind {
+	case "list":
+		if _, ok := node.(yaml.List); !ok {
+			s.report(path, false, "should be a list")
+			return false
+		}
+	case "map":
+		if _, ok := node.(yaml.Map); !ok {
+			s.report(path, false, "should be a map")
+			return false
+		}
+	default:
+		scalar, ok := node.(yaml.Scalar)
+		if !ok {
+			s.report(path, false, "should be a %s value", key.Kind)
+			return false
+		}
+		value := unquote(scalar.String())
+		if key.Kind == "int" {
+			if _, err := strconv.ParseInt(value, 10, 64); err != nil {
+				s.report(path, false, "should be an int: %s", value)
+				return false
+			}
+		} else if key.Kind == "bool" {
+			if _, err := strconv.ParseBool(value); err != nil {
+				s.report(path, false, "should be a bool: %s", value)
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// checkPresent reports the required key if it's not present. Keys inside
+// list items are checked in each one of them.
+func (s *schema) checkPresent(node yaml.Node, path string, key Key) {
+	rest := key.Path[len(indexRe.ReplaceAllString(path, "[]")):]
+	rest = strings.TrimPrefix(rest, ".")
+
+	i := strings.Index(rest, "[]")
+	if i == -1 {
+		if _, err := yaml.Child(node, rest); err != nil {
+			s.report(joinPath(path, rest), false, "required value missing")
+		}
+		return
+	}
+
+	listPath := joinPath(path, rest[:i])
+	list, err := yaml.Child(node, rest[:i])
+	if err != nil {
+		// The missing list is reported by its own declaration if it's required
+		return
+	}
+	items, ok := list.(yaml.List)
+	if !ok {
+		return
+	}
+	for n, item := range items {
+		s.checkPresent(item, fmt.Sprintf("%s[%d]", listPath, n), key)
+	}
+}
+
+func joinPath(path, child string) string {
+	if path == "" {
+		return child
+	}
+	return path + "." + child
+}
+
+// parentPath removes the last element of a path.
+func parentPath(path string) string {
+	i := strings.LastIndexAny(path, ".[")
+	if i == -1 {
+		return ""
+	}
+	return path[:i]
+}
+
+func unquote(s string) string {
+	if len(s) > 1 && s[0] == '"' && s[len(s)-1] == '"' {
+		return s[1 : len(s)-1]
+	}
+	return s
+}
@@ -12,7 +12,7 @@ var userConfigsPath string
 func PrepareUserConfigs() error {
 	u, err := user.Current()
 	if err != nil {
-		return fmt.Errorf("cannot get current user: %s", err)
+		return fmt.Errorf("cannot get current user: %w", err)
 	}
 
 	userConfigsPath = filepath.Join(u.HomeDir, ".cb")
@@ -28,12 +28,12 @@ func PrepareUserConfigs() error {
 
 	// Another kind of error
 	if !os.IsNotExist(err) {
-		return fmt.Errorf("cannot stat user configs: %s", err)
+		return fmt.Errorf("cannot stat user configs: %w", err)
 	}
 
 	// Create it if not present
 	if err := os.MkdirAll(userConfigsPath, 0755); err != nil {
-		return fmt.Errorf("cannot create user configs: %s", err)
+		return fmt.Errorf("cannot create user configs: %w", err)
 	}
 
 	return nil
@@ -0,0 +1,97 @@
+// Package events emits the machine-readable output of cb when running
+// with -format=json: one JSON object per line in the standard output,
+// while the human logs go to the standard error.
+package events
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ernestokarim/cb/config"
+)
+
+// Types of events.
+const (
+	TaskStarted  = "task-started"
+	TaskFinished = "task-finished"
+	Exec         = "exec"
+	File         = "file"
+	Warning      = "warning"
+	Error        = "error"
+)
+
+// Event is a line of the JSON output. Only the fields that make sense for
+// each type are present.
+type Event struct {
+	Type string    `json:"type"`
+	Time time.Time `json:"time"`
+	Task string    `json:"task,omitempty"`
+
+	// Status of a finished task: ok, cached, failed, skipped or cancelled
+	Status string `json:"status,omitempty"`
+
+	// Seconds spent by a task or a command
+	Duration float64 `json:"duration,omitempty"`
+
+	Command   string `json:"command,omitempty"`
+	Path      string `json:"path,omitempty"`
+	Operation string `json:"operation,omitempty"`
+	Message   string `json:"message,omitempty"`
+
+	// Chain of errors that caused the failure, from the outer to the inner one
+	Causes []string `json:"causes,omitempty"`
+}
+
+var mutex sync.Mutex
+
+// Enabled returns true if cb should emit events.
+func Enabled() bool {
+	return *config.Format == "json"
+}
+
+// Emit writes the event to the output if the JSON format is enabled.
+func Emit(e *Event) {
+	if !Enabled() {
+		return
+	}
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+	// Encoding this struct can't fail
+	json.NewEncoder(os.Stdout).Encode(e)
+}
+
+// Fail emits an error event with the chain of causes of err.
+func Fail(task string, err error) {
+	Emit(&Event{
+		Type:    Error,
+		Task:    task,
+		Message: err.Error(),
+		Causes:  Causes(err),
+	})
+}
+
+// Causes returns the chain of errors wrapped by err, from the outer to the
+// inner one. In cb the errors are wrapped adding a prefix with %w, like
+// `parent error: %w`; each cause is the message without the wrapped one.
+func Causes(err error) []string {
+	causes := []string{}
+	for err != nil {
+		msg := err.Error()
+		inner := errors.Unwrap(err)
+		if inner != nil && strings.HasSuffix(msg, inner.Error()) {
+			msg = strings.TrimSuffix(msg, inner.Error())
+			msg = strings.TrimSuffix(strings.TrimSpace(msg), ":")
+		}
+		causes = append(causes, msg)
+		err = inner
+	}
+	return causes
+}
@@ -0,0 +1,35 @@
+package events
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestCauses(t *testing.T) {
+	inner := fmt.Errorf("open config.yaml: no such file")
+	tests := []struct {
+		err  error
+		want []string
+	}{
+		{inner, []string{"open config.yaml: no such file"}},
+		{
+			fmt.Errorf("run queue failed: %w", fmt.Errorf("task failed (sass@0): %w", inner)),
+			[]string{"run queue failed", "task failed (sass@0)", "open config.yaml: no such file"},
+		},
+		{
+			fmt.Errorf("url http://localhost:8080/ failed: %w", inner),
+			[]string{"url http://localhost:8080/ failed", "open config.yaml: no such file"},
+		},
+		{
+			// The wrapped error is not at the end of the message
+			fmt.Errorf("%w (while reading)", inner),
+			[]string{"open config.yaml: no such file (while reading)", "open config.yaml: no such file"},
+		},
+	}
+	for _, test := range tests {
+		if got := Causes(test.err); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("Causes(%q) = %q, want %q", test.err, got, test.want)
+		}
+	}
+}
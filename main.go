@@ -1,17 +1,25 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"log"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
 
+	"github.com/ernestokarim/cb/cache"
 	"github.com/ernestokarim/cb/colors"
 	"github.com/ernestokarim/cb/config"
+	"github.com/ernestokarim/cb/events"
 	"github.com/ernestokarim/cb/registry"
 )
 
 func main() {
 	if err := run(); err != nil {
+		events.Fail("", err)
 		log.Fatalf("%s%s%s\n", colors.Red, err, colors.Reset)
 	}
 }
@@ -20,51 +28,151 @@ func run() error {
 	flag.Parse()
 	log.SetFlags(log.Ltime)
 
+	switch *config.Format {
+	case "text":
+	case "json":
+		// The standard output is reserved for the events
+		colors.SetNoColors()
+		log.SetOutput(os.Stderr)
+	default:
+		return fmt.Errorf("unknown output format: %s", *config.Format)
+	}
+
 	if *config.NoColors {
 		colors.SetNoColors()
 	}
 
+	if err := config.PrepareUserConfigs(); err != nil {
+		return err
+	}
+
+	// The edits of the config don't resolve its variables, so they can
+	// fix the ones that are missing
+	args := flag.Args()
+	load := config.Load
+	raw := len(args) > 0 && taskName(args[0]) == "config:set"
+	if raw {
+		load = config.LoadRaw
+	}
+	c, err := load()
+	if err != nil {
+		return fmt.Errorf("config loading failed: %w", err)
+	}
+	if c != nil {
+		if err := registry.LoadConfigTasks(c); err != nil {
+			return fmt.Errorf("config tasks loading failed: %w", err)
+		}
+	}
+
 	if *config.Help {
 		usage()
 		return nil
 	}
-	args := flag.Args()
 	if len(args) == 0 {
 		usage()
 		return nil
 	}
 
-	c, err := config.Load()
-	if err != nil {
-		return fmt.Errorf("config loading failed: %s", err)
-	}
-	if c == nil && !isNoConfigTask(args[0]) {
+	if c == nil && !isNoConfigTask(args[0]) && !registry.IsPlugin(args[0]) {
 		return fmt.Errorf("config file not found")
 	}
 
-	if err := config.PrepareUserConfigs(); err != nil {
-		return err
-	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go handleSignals(cancel)
 
-	q := &registry.Queue{}
+	q := registry.NewQueue(ctx)
 	for _, task := range args {
 		q.AddTask(task)
 	}
-	if err := q.RunWithTimer(c); err != nil {
-		return err
+	if raw {
+		for _, t := range q.Queued() {
+			if taskName(t) != "config:set" {
+				return fmt.Errorf("config:set cannot run with other tasks: %s", t)
+			}
+		}
 	}
+	if c != nil {
+		if err := checkConfig(c, q.Queued()); err != nil {
+			return err
+		}
+	}
+	runErr := q.RunWithTimer(c)
+	if err := cache.Save(); err != nil && runErr == nil {
+		return fmt.Errorf("save cache failed: %w", err)
+	}
+	if err := cache.PruneStore(); err != nil && runErr == nil {
+		return fmt.Errorf("prune store failed: %w", err)
+	}
+	return runErr
+}
 
+// checkConfig validates the config file before running any task, so
+// a typo doesn't stop a build halfway through. The help & config tasks
+// don't need a valid config, they are used to fix it.
+func checkConfig(c *config.Config, queued []string) error {
+	tasks := []string{}
+	for _, t := range queued {
+		name := taskName(t)
+		if name != "help" && !strings.HasPrefix(name, "config:") {
+			tasks = append(tasks, t)
+		}
+	}
+	if len(tasks) == 0 {
+		return nil
+	}
+
+	failed := false
+	problems := registry.CheckConfig(c, tasks)
+	for _, p := range problems {
+		if p.Warning {
+			log.Printf("%s%s%s\n", colors.Yellow, p, colors.Reset)
+			continue
+		}
+		log.Printf("%s%s%s\n", colors.Red, p, colors.Reset)
+		failed = true
+	}
+	if len(problems) > 0 && *config.Verbose {
+		log.Printf("%sconfig checked against the keys of: %s%s\n", colors.Yellow,
+			strings.Join(tasks, ", "), colors.Reset)
+	}
+	if failed {
+		return fmt.Errorf("invalid config file, run `cb config:check` for more details")
+	}
 	return nil
 }
 
+// handleSignals cancels the running tasks when receiving an interrupt, to
+// stop their child processes and clean up. A second one exits directly.
+func handleSignals(cancel context.CancelFunc) {
+	signals := make(chan os.Signal, 2)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+
+	sig := <-signals
+	log.Printf("%sreceived %s, stopping the tasks...%s\n", colors.Yellow, sig, colors.Reset)
+	cancel()
+
+	<-signals
+	os.Exit(1)
+}
+
 func usage() {
 	fmt.Println("\n Usage: cb [target] [options...]")
 	flag.PrintDefaults()
-	registry.PrintTasks()
+	registry.PrintTasks(os.Stdout)
+}
+
+// taskName removes the version & the inline arguments of a task.
+func taskName(task string) string {
+	if i := strings.IndexAny(task, "@["); i != -1 {
+		return task[:i]
+	}
+	return task
 }
 
 func isNoConfigTask(task string) bool {
 	tasks := []string{
+		"help",
 		"init:laravel",
 		"update",
 		"update:check",
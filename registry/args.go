@@ -0,0 +1,259 @@
+package registry
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Args are the values of the arguments declared by a task with Info.Args.
+// They can be passed in several ways:
+//
+//	cb angular:service[foo,app.services]
+//	cb angular:service[name=foo,module=app.services]
+//	cb angular:service --name=foo --module=app.services
+//	cb angular:service foo app.services
+//
+// The last one is the old positional style, that takes the next entries
+// of the queue, and it's only used if no other arguments are present.
+//
+// Arguments are declared as `<name>` (required) or `[name]` (optional),
+// with an optional type (`<port:int>`, `[force:bool]`) that is checked
+// before running the task. The last one can be `[name...]` to collect the
+// rest of the positional values, until the next known task; with the `task`
+// type (`[tasks:task...]`) the values are task names, so it takes all of
+// them, checking they exist.
+type Args struct {
+	values map[string]string
+	lists  map[string][]string
+}
+
+// String returns the value of the argument, or an empty string if it was
+// not passed.
+func (a *Args) String(name string) string {
+	if a == nil {
+		return ""
+	}
+	return a.values[name]
+}
+
+// Int returns the value of an argument declared with the `int` type, or
+// zero if it was not passed.
+func (a *Args) Int(name string) int {
+	n, _ := strconv.Atoi(a.String(name))
+	return n
+}
+
+// Bool returns the value of an argument declared with the `bool` type.
+func (a *Args) Bool(name string) bool {
+	b, _ := strconv.ParseBool(a.String(name))
+	return b
+}
+
+// List returns the values collected by a `[name...]` argument.
+func (a *Args) List(name string) []string {
+	if a == nil {
+		return nil
+	}
+	return a.lists[name]
+}
+
+// argSpec is the parsed declaration of an argument.
+type argSpec struct {
+	name     string
+	kind     string
+	required bool
+	rest     bool
+}
+
+func parseArg(spec string) argSpec {
+	arg := argSpec{name: spec, required: true}
+	if len(spec) > 1 {
+		switch {
+		case spec[0] == '<' && spec[len(spec)-1] == '>':
+			arg.name = spec[1 : len(spec)-1]
+		case spec[0] == '[' && spec[len(spec)-1] == ']':
+			arg.name = spec[1 : len(spec)-1]
+			arg.required = false
+		}
+	}
+	if strings.HasSuffix(arg.name, "...") {
+		arg.name = arg.name[:len(arg.name)-3]
+		arg.rest = true
+	}
+	if i := strings.Index(arg.name, ":"); i != -1 {
+		arg.name, arg.kind = arg.name[:i], arg.name[i+1:]
+	}
+	return arg
+}
+
+// check validates the value against the type of the argument.
+func (arg argSpec) check(value string) error {
+	var err error
+	switch arg.kind {
+	case "", "string":
+	case "int":
+		_, err = strconv.Atoi(value)
+	case "bool":
+		_, err = strconv.ParseBool(value)
+	case "task":
+		if !isTaskCall(value) {
+			return fmt.Errorf("task not found: %s", value)
+		}
+	default:
+		return fmt.Errorf("unknown type of argument %s: %s", arg.name, arg.kind)
+	}
+	if err != nil {
+		return fmt.Errorf("argument %s should be of type %s: %s", arg.name, arg.kind, value)
+	}
+	return nil
+}
+
+// parseCall splits a queue entry with the `name@version[arg1,arg2]` format.
+func parseCall(t string) (string, int, []string, error) {
+	var inline []string
+	if i := strings.Index(t, "["); i != -1 && strings.HasSuffix(t, "]") {
+		if list := t[i+1 : len(t)-1]; list != "" {
+			inline = strings.Split(list, ",")
+		}
+		t = t[:i]
+	}
+
+	name, version, err := parseTask(t)
+	if err != nil {
+		return "", 0, nil, err
+	}
+	return name, version, inline, nil
+}
+
+// takeArgs builds the arguments of the job from the inline ones and the
+// `--key=value` entries that follow it in the queue, falling back to the
+// positional style if none of them are present.
+func (q *Queue) takeArgs(j *job, inline []string) (*Args, error) {
+	specs := []argSpec{}
+	byName := map[string]argSpec{}
+	for _, s := range j.info.args {
+		arg := parseArg(s)
+		specs = append(specs, arg)
+		byName[arg.name] = arg
+	}
+	usage := j.info.usage(j.name)
+
+	named := map[string]string{}
+	positional := []string{}
+	setNamed := func(key, value string) error {
+		if _, ok := byName[key]; !ok {
+			return fmt.Errorf("unknown argument %s, usage: %s", key, usage)
+		}
+		if _, ok := named[key]; ok {
+			return fmt.Errorf("argument %s passed twice, usage: %s", key, usage)
+		}
+		named[key] = value
+		return nil
+	}
+
+	for _, item := range inline {
+		if i := strings.Index(item, "="); i != -1 {
+			if _, ok := byName[item[:i]]; ok {
+				if err := setNamed(item[:i], item[i+1:]); err != nil {
+					return nil, err
+				}
+				continue
+			}
+		}
+		positional = append(positional, item)
+	}
+
+	// Unknown named arguments are kept verbatim if the task collects the
+	// rest of them (e.g. plugins)
+	var rest *argSpec
+	if len(specs) > 0 && specs[len(specs)-1].rest {
+		rest = &specs[len(specs)-1]
+	}
+	extra := []string{}
+	for {
+		next := q.NextTask()
+		if !strings.HasPrefix(next, "--") {
+			break
+		}
+		q.RemoveNextTask()
+
+		key, value := next[2:], "true"
+		if i := strings.Index(key, "="); i != -1 {
+			key, value = key[:i], key[i+1:]
+		}
+		if _, ok := byName[key]; !ok && rest != nil {
+			extra = append(extra, next)
+			continue
+		}
+		if err := setNamed(key, value); err != nil {
+			return nil, err
+		}
+	}
+
+	if inline == nil && len(named) == 0 && len(extra) == 0 {
+		positional = q.takePositional(specs)
+	}
+
+	args := &Args{
+		values: map[string]string{},
+		lists:  map[string][]string{},
+	}
+	for _, arg := range specs {
+		if arg.rest {
+			for _, value := range positional {
+				if err := arg.check(value); err != nil {
+					return nil, fmt.Errorf("%s, usage: %s", err, usage)
+				}
+			}
+			args.lists[arg.name] = append(positional, extra...)
+			positional = nil
+			continue
+		}
+
+		value, ok := named[arg.name]
+		if !ok && len(positional) > 0 {
+			value, positional, ok = positional[0], positional[1:], true
+		}
+		if !ok {
+			if arg.required {
+				return nil, fmt.Errorf("missing argument %s, usage: %s", arg.name, usage)
+			}
+			continue
+		}
+		if err := arg.check(value); err != nil {
+			return nil, fmt.Errorf("%s, usage: %s", err, usage)
+		}
+		args.values[arg.name] = value
+	}
+	if len(positional) > 0 {
+		return nil, fmt.Errorf("too many arguments %v, usage: %s", positional, usage)
+	}
+
+	return args, nil
+}
+
+// takePositional removes from the queue the entries used as arguments
+// in the old positional style. Required arguments take the next entry;
+// optional and rest arguments stop at the next known task, unless they
+// collect task names.
+func (q *Queue) takePositional(specs []argSpec) []string {
+	values := []string{}
+	for _, arg := range specs {
+		for {
+			next := q.NextTask()
+			if next == "" {
+				break
+			}
+			if (arg.rest || !arg.required) && arg.kind != "task" && isQueuedTask(next) {
+				return values
+			}
+			values = append(values, next)
+			q.RemoveNextTask()
+			if !arg.rest {
+				break
+			}
+		}
+	}
+	return values
+}
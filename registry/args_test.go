@@ -0,0 +1,222 @@
+package registry
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/ernestokarim/cb/config"
+)
+
+func init() {
+	noop := func(c *config.Config, q *Queue) error { return nil }
+	NewTask("args:first", 0, noop)
+	NewTask("args:second", 0, noop).Requires("args:first")
+}
+
+func TestParseArg(t *testing.T) {
+	tests := []struct {
+		spec string
+		want argSpec
+	}{
+		{"<name>", argSpec{name: "name", required: true}},
+		{"[name]", argSpec{name: "name"}},
+		{"<port:int>", argSpec{name: "port", kind: "int", required: true}},
+		{"[force:bool]", argSpec{name: "force", kind: "bool"}},
+		{"[args...]", argSpec{name: "args", rest: true}},
+		{"[tasks:task...]", argSpec{name: "tasks", kind: "task", rest: true}},
+		{"name", argSpec{name: "name", required: true}},
+	}
+	for _, test := range tests {
+		if got := parseArg(test.spec); got != test.want {
+			t.Errorf("parseArg(%q) = %+v, want %+v", test.spec, got, test.want)
+		}
+	}
+}
+
+func TestParseCall(t *testing.T) {
+	tests := []struct {
+		call    string
+		name    string
+		version int
+		inline  []string
+	}{
+		{"build", "build", -1, nil},
+		{"build@2", "build", 2, nil},
+		{"angular:service[foo,bar]", "angular:service", -1, []string{"foo", "bar"}},
+		{"angular:service@0[name=foo]", "angular:service", 0, []string{"name=foo"}},
+		{"angular:service[]", "angular:service", -1, nil},
+	}
+	for _, test := range tests {
+		name, version, inline, err := parseCall(test.call)
+		if err != nil {
+			t.Errorf("parseCall(%q) failed: %s", test.call, err)
+			continue
+		}
+		if name != test.name || version != test.version || !reflect.DeepEqual(inline, test.inline) {
+			t.Errorf("parseCall(%q) = %q, %d, %q, want %q, %d, %q", test.call, name, version,
+				inline, test.name, test.version, test.inline)
+		}
+	}
+
+	if _, _, _, err := parseCall("build@x"); err == nil {
+		t.Errorf("parseCall should fail with a bad version")
+	}
+}
+
+func TestTakeArgs(t *testing.T) {
+	tests := []struct {
+		specs  []string
+		call   string
+		queue  []string
+		values map[string]string
+		lists  map[string][]string
+		rest   []string
+		fails  bool
+	}{
+		{
+			specs:  []string{"<name>", "[module]"},
+			call:   "t[foo,app]",
+			queue:  []string{"build"},
+			values: map[string]string{"name": "foo", "module": "app"},
+			rest:   []string{"build"},
+		},
+		{
+			specs:  []string{"<name>", "[module]"},
+			call:   "t[module=app,name=foo]",
+			values: map[string]string{"name": "foo", "module": "app"},
+		},
+		{
+			specs:  []string{"<name>", "[module]"},
+			call:   "t",
+			queue:  []string{"--name=foo", "--module=app", "other"},
+			values: map[string]string{"name": "foo", "module": "app"},
+			rest:   []string{"other"},
+		},
+		{
+			specs:  []string{"<name>", "[module]"},
+			call:   "t",
+			queue:  []string{"foo", "app", "other"},
+			values: map[string]string{"name": "foo", "module": "app"},
+			rest:   []string{"other"},
+		},
+		{
+			// Optional arguments stop at the next task
+			specs:  []string{"<name>", "[module]"},
+			call:   "t",
+			queue:  []string{"foo", "args:first"},
+			values: map[string]string{"name": "foo"},
+			rest:   []string{"args:first"},
+		},
+		{
+			specs:  []string{"[force:bool]"},
+			call:   "t",
+			queue:  []string{"--force"},
+			values: map[string]string{"force": "true"},
+		},
+		{
+			specs: []string{"<port:int>"},
+			call:  "t[abc]",
+			fails: true,
+		},
+		{
+			specs: []string{"<name>"},
+			call:  "t",
+			fails: true,
+		},
+		{
+			specs: []string{"<name>"},
+			call:  "t[foo,bar]",
+			fails: true,
+		},
+		{
+			// Values can contain `=` if it's not an argument name
+			specs:  []string{"<name>"},
+			call:   "t[other=foo]",
+			values: map[string]string{"name": "other=foo"},
+		},
+		{
+			specs: []string{"<name>"},
+			call:  "t",
+			queue: []string{"--name=foo", "--name=bar"},
+			fails: true,
+		},
+		{
+			// Rest arguments stop at the next task
+			specs:  []string{"[args...]"},
+			call:   "t",
+			queue:  []string{"a", "b", "args:first", "c"},
+			values: map[string]string{},
+			lists:  map[string][]string{"args": {"a", "b"}},
+			rest:   []string{"args:first", "c"},
+		},
+		{
+			// Unknown named arguments are kept by the rest ones
+			specs:  []string{"[args...]"},
+			call:   "t",
+			queue:  []string{"--verbose", "--out=x"},
+			values: map[string]string{},
+			lists:  map[string][]string{"args": {"--verbose", "--out=x"}},
+		},
+		{
+			// Task names are taken by the task arguments
+			specs:  []string{"[tasks:task...]"},
+			call:   "t",
+			queue:  []string{"args:first", "args:second@0"},
+			values: map[string]string{},
+			lists:  map[string][]string{"tasks": {"args:first", "args:second@0"}},
+		},
+		{
+			specs:  []string{"[tasks:task...]"},
+			call:   "t[args:second]",
+			queue:  []string{"args:first"},
+			values: map[string]string{},
+			lists:  map[string][]string{"tasks": {"args:second"}},
+			rest:   []string{"args:first"},
+		},
+		{
+			specs: []string{"[tasks:task...]"},
+			call:  "t",
+			queue: []string{"args:first", "missing"},
+			fails: true,
+		},
+	}
+	for _, test := range tests {
+		q := &Queue{}
+		q.AddTasks(test.queue)
+
+		name, _, inline, err := parseCall(test.call)
+		if err != nil {
+			t.Fatal(err)
+		}
+		j := &job{name: name, info: &Info{Name: name, args: test.specs}}
+		args, err := q.takeArgs(j, inline)
+		if test.fails {
+			if err == nil {
+				t.Errorf("%v %s %v should fail", test.specs, test.call, test.queue)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%v %s %v failed: %s", test.specs, test.call, test.queue, err)
+			continue
+		}
+
+		if test.lists == nil {
+			test.lists = map[string][]string{}
+		}
+		if !reflect.DeepEqual(args.values, test.values) {
+			t.Errorf("%v %s %v: values = %v, want %v", test.specs, test.call, test.queue,
+				args.values, test.values)
+		}
+		if !reflect.DeepEqual(args.lists, test.lists) {
+			t.Errorf("%v %s %v: lists = %v, want %v", test.specs, test.call, test.queue,
+				args.lists, test.lists)
+		}
+		if rest := q.tasks; len(rest) > 0 || len(test.rest) > 0 {
+			if !reflect.DeepEqual(rest, test.rest) {
+				t.Errorf("%v %s %v: queue = %v, want %v", test.specs, test.call, test.queue,
+					rest, test.rest)
+			}
+		}
+	}
+}
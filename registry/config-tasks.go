@@ -0,0 +1,94 @@
+package registry
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+
+	"github.com/ernestokarim/cb/config"
+	"github.com/ernestokarim/cb/utils"
+)
+
+// LoadConfigTasks registers the tasks declared in the `tasks` section of the
+// config file. Each one can be an alias of a list of tasks, run in order as
+// its prerequisites, or a shell command. Both of them accept a list of
+// prerequisites too, that run before the tasks of the alias.
+//
+//	tasks:
+//	  - name: release
+//	    description: build & upload the app
+//	    tasks:
+//	      - build
+//	      - push
+//	  - name: artisan:migrate
+//	    command: php
+//	    args:
+//	      - artisan
+//	      - migrate
+//	    dir: ..
+//	    env:
+//	      - APP_ENV=production
+//	    requires:
+//	      - update:check
+func LoadConfigTasks(c *config.Config) error {
+	var decl struct {
+		Tasks []*configTask
+	}
+	if err := c.Decode(&decl, ""); err != nil {
+		return err
+	}
+
+	for _, t := range decl.Tasks {
+		if tasks[t.Name] != nil {
+			return fmt.Errorf("task already registered: %s", t.Name)
+		}
+
+		var f Task
+		if len(t.Tasks) > 0 && t.Command != "" {
+			return fmt.Errorf("task `%s` cannot have both a command and a list of tasks", t.Name)
+		} else if len(t.Tasks) > 0 {
+			f = aliasTask
+		} else if t.Command != "" {
+			f = shellTask(t.Command, t.Args, t.Dir, t.Env)
+		} else {
+			return fmt.Errorf("task `%s` needs a command or a list of tasks", t.Name)
+		}
+
+		info := NewUserTask(t.Name, 0, f).
+			Describe(t.Description).
+			Requires(t.Requires...)
+		info.steps = t.Tasks
+	}
+	return nil
+}
+
+// configTask is a task declared in the config file.
+type configTask struct {
+	Name        string `config:"name,required"`
+	Description string
+	Tasks       []string
+	Command     string
+	Args        []string
+	Dir         string
+	Env         []string
+	Requires    []string
+}
+
+// aliasTask does nothing, its tasks already ran as prerequisites.
+func aliasTask(c *config.Config, q *Queue) error {
+	return nil
+}
+
+func shellTask(command string, args []string, dir string, env []string) Task {
+	return func(c *config.Config, q *Queue) error {
+		ctx := q.Context()
+
+		cmd := exec.Command(command, args...)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(), env...)
+		if err := utils.ExecCommand(ctx, cmd); err != nil {
+			return fmt.Errorf("command failed: %w", err)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,76 @@
+package registry
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ernestokarim/cb/colors"
+	"github.com/ernestokarim/cb/events"
+)
+
+// errTasksFailed is returned in keep-going mode when some of the tasks
+// failed; the errors themselves are stored in the queue for the report.
+var errTasksFailed = errors.New("some tasks failed")
+
+// failure is a task that failed or that was skipped because one of its
+// prerequisites failed, in keep-going mode.
+type failure struct {
+	key     string
+	err     error
+	skipped bool
+}
+
+func (q *Queue) addFailure(key string, err error, skipped bool) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	q.failed[key] = true
+	q.failures = append(q.failures, &failure{key, err, skipped})
+
+	if skipped {
+		events.Emit(&events.Event{
+			Type:    events.TaskFinished,
+			Task:    key,
+			Status:  "skipped",
+			Message: err.Error(),
+		})
+	}
+}
+
+func (q *Queue) isFailed(key string) bool {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	return q.failed[key]
+}
+
+// printFailures prints the report of all the failures of the queue and
+// returns the error that should stop cb, if any.
+func (q *Queue) printFailures() error {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	if len(q.failures) == 0 {
+		return nil
+	}
+
+	failed := 0
+	for _, f := range q.failures {
+		if !f.skipped {
+			failed++
+		}
+	}
+	err := fmt.Errorf("%d tasks failed, %d skipped", failed, len(q.failures)-failed)
+	if events.Enabled() {
+		return err
+	}
+
+	fmt.Printf("\n%s * FAILURES:%s\n", colors.Red, colors.Reset)
+	for _, f := range q.failures {
+		if f.skipped {
+			fmt.Printf("    %s- %s%s: %s\n", colors.Yellow, f.key, colors.Reset, f.err)
+			continue
+		}
+		fmt.Printf("    %sx %s%s: %s\n", colors.Red, f.key, colors.Reset, f.err)
+	}
+	fmt.Println()
+
+	return err
+}
@@ -0,0 +1,68 @@
+package registry
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// PrintHelp prints to w the usage of a task, with all the metadata it declares.
+func PrintHelp(w io.Writer, name string) error {
+	t, version, err := parseTask(name)
+	if err != nil {
+		return err
+	}
+	info, err := lookupTask(t, version)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(w, "\n Usage: %s\n", info.usage(t))
+	if info.desc != "" {
+		fmt.Fprintf(w, "\n %s\n", info.desc)
+	}
+	if path, err := findPlugin(t); err == nil && tasks[t] == nil {
+		fmt.Fprintf(w, "\n * PLUGIN: %s\n", path)
+	}
+
+	if versions := taskVersions(t); len(versions) > 1 {
+		fmt.Fprintf(w, "\n * VERSIONS: %s\n", strings.Join(versions, ", "))
+	}
+	if deps := append(append([]string{}, info.first...), info.deps...); len(deps) > 0 {
+		fmt.Fprintf(w, "\n * REQUIRES: %s\n", strings.Join(deps, ", "))
+	}
+	if len(info.steps) > 0 {
+		fmt.Fprintf(w, "\n * RUNS: %s\n", strings.Join(info.steps, ", "))
+	}
+	if len(info.configs) > 0 {
+		keys := []string{}
+		for _, key := range info.configs {
+			keys = append(keys, key.String())
+		}
+		fmt.Fprintf(w, "\n * CONFIG: %s\n", strings.Join(keys, ", "))
+	}
+	fmt.Fprintln(w)
+
+	return nil
+}
+
+// usage returns the command line needed to call the task.
+func (info *Info) usage(name string) string {
+	parts := append([]string{"cb", name}, info.args...)
+	return strings.Join(parts, " ")
+}
+
+func taskVersions(name string) []string {
+	ints := []int{}
+	for v := range tasks[name] {
+		ints = append(ints, v)
+	}
+	sort.Ints(ints)
+
+	versions := []string{}
+	for _, v := range ints {
+		versions = append(versions, fmt.Sprintf("%d", v))
+	}
+	return versions
+}
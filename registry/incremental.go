@@ -0,0 +1,242 @@
+package registry
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/ernestokarim/cb/cache"
+	"github.com/ernestokarim/cb/colors"
+	"github.com/ernestokarim/cb/config"
+	"github.com/ernestokarim/cb/events"
+	"github.com/ernestokarim/cb/utils"
+)
+
+var (
+	inputs = cache.Register("inputs")
+
+	// Versions of the tools, asked once per run
+	tools      = map[string]string{}
+	toolsMutex = &sync.Mutex{}
+
+	configRefRe = regexp.MustCompile(`\$\{([^}]+)\}`)
+)
+
+// call runs the task of the job, or restores its outputs if a previous
+// build ran it with the same inputs, config & tools. Only the tasks that
+// declare their inputs are cached; -rebuild runs them anyway, saving
+// the new outputs.
+func (q *Queue) call(c *config.Config, j *job, view *Queue, rec *record) error {
+	if (len(j.info.inputs) == 0 && len(j.info.inputsFrom) == 0) || *config.DryRun {
+		return j.info.f(c, view)
+	}
+
+	fp, err := fingerprint(view.Context(), c, j)
+	if err != nil {
+		return fmt.Errorf("fingerprint failed: %w", err)
+	}
+	store, err := cache.OpenStore()
+	if err != nil {
+		return err
+	}
+
+	if !*config.Rebuild {
+		outputs, err := store.Lookup(fp)
+		if err != nil {
+			return err
+		}
+		if outputs != nil {
+			for _, output := range outputs {
+				if err := store.Restore(output); err != nil {
+					return err
+				}
+				events.Emit(&events.Event{
+					Type:      events.File,
+					Operation: "restore",
+					Path:      output.Path,
+				})
+			}
+			if *config.Verbose {
+				utils.Logf(view.Context(), "%srestored %d outputs of %s%s\n", colors.Green,
+					len(outputs), j.key(), colors.Reset)
+			}
+			rec.cached = true
+			return nil
+		}
+	}
+
+	if err := j.info.f(c, view); err != nil {
+		return err
+	}
+
+	paths, err := outputFiles(c, j.info.outputs, view.produced)
+	if err != nil {
+		return err
+	}
+	if err := store.Save(fp, paths); err != nil {
+		return fmt.Errorf("save outputs failed: %w", err)
+	}
+	return nil
+}
+
+// fingerprint returns the hash of everything that determines the outputs
+// of the job: the task itself, its arguments, the content of the input
+// files, the config keys it reads and the versions of its tools.
+func fingerprint(ctx context.Context, c *config.Config, j *job) (string, error) {
+	h := sha1.New()
+	fmt.Fprintf(h, "task %s\n", j.key())
+
+	if j.args != nil {
+		writeArgs(h, j.args)
+	}
+
+	patterns := expandPatterns(c, j.info.inputs)
+	if c != nil {
+		for _, f := range j.info.inputsFrom {
+			computed, err := f(c)
+			if err != nil {
+				return "", fmt.Errorf("compute inputs failed: %w", err)
+			}
+			patterns = append(patterns, computed...)
+		}
+	}
+	files, err := walkFiles(patterns)
+	if err != nil {
+		return "", err
+	}
+	for _, file := range files {
+		sum, err := inputs.Hash(file)
+		if err != nil {
+			return "", fmt.Errorf("hash input failed: %w", err)
+		}
+		fmt.Fprintf(h, "input %s %s\n", file, sum)
+	}
+
+	if c != nil {
+		for _, key := range j.info.configs {
+			value, _ := c.SecretSummary(key.Path)
+			fmt.Fprintf(h, "config %s=%s\n", key.Path, value)
+		}
+	}
+
+	for _, tool := range j.info.tools {
+		version, err := toolVersion(ctx, tool)
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(h, "tool %s\n", version)
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func writeArgs(h hash.Hash, args *Args) {
+	names := []string{}
+	for name := range args.values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(h, "arg %s=%q\n", name, args.values[name])
+	}
+
+	names = names[:0]
+	for name := range args.lists {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(h, "arg %s=%q\n", name, args.lists[name])
+	}
+}
+
+// toolVersion runs the version command of a tool the first time it's needed.
+// Its output is used even if it fails, some tools exit with an error
+// after printing their version.
+func toolVersion(ctx context.Context, tool []string) (string, error) {
+	line := strings.Join(tool, " ")
+
+	toolsMutex.Lock()
+	defer toolsMutex.Unlock()
+	if version, ok := tools[line]; ok {
+		return version, nil
+	}
+
+	output, err := exec.CommandContext(ctx, tool[0], tool[1:]...).CombinedOutput()
+	if _, ok := err.(*exec.ExitError); err != nil && !ok {
+		return "", fmt.Errorf("get version of %s failed: %w", tool[0], err)
+	}
+	version := strings.TrimSpace(string(output))
+	tools[line] = version
+	return version, nil
+}
+
+// expandPatterns replaces the `${path}` references of the patterns with the
+// values of the config, one pattern for each of them. `${path:base}` uses
+// only the last element of the values.
+func expandPatterns(c *config.Config, patterns []string) []string {
+	result := []string{}
+	for _, pattern := range patterns {
+		match := configRefRe.FindStringSubmatchIndex(pattern)
+		if match == nil {
+			result = append(result, pattern)
+			continue
+		}
+		if c == nil {
+			continue
+		}
+
+		path := pattern[match[2]:match[3]]
+		base := strings.HasSuffix(path, ":base")
+		path = strings.TrimSuffix(path, ":base")
+
+		expanded := []string{}
+		for _, value := range c.Values(path) {
+			if base {
+				value = filepath.Base(value)
+			}
+			expanded = append(expanded, pattern[:match[0]]+value+pattern[match[1]:])
+		}
+		result = append(result, expandPatterns(c, expanded)...)
+	}
+	return result
+}
+
+// walkFiles returns the files matched by the patterns, sorted. Folders
+// match all the files inside them; missing ones don't match anything.
+func walkFiles(patterns []string) ([]string, error) {
+	files := []string{}
+	if len(patterns) == 0 {
+		return files, nil
+	}
+
+	fn := func(path string, info os.FileInfo) error {
+		if info.Mode().IsRegular() {
+			files = append(files, path)
+		}
+		return nil
+	}
+	if err := utils.NewWalker(patterns...).Walk(fn); err != nil {
+		return nil, err
+	}
+	return files, nil
+}
+
+// outputFiles returns the files present in the declared outputs after
+// running the task, including the ones produced at runtime.
+func outputFiles(c *config.Config, patterns, produced []string) ([]string, error) {
+	files, err := walkFiles(append(expandPatterns(c, patterns), produced...))
+	if err != nil {
+		return nil, fmt.Errorf("walk outputs failed: %w", err)
+	}
+	return files, nil
+}
@@ -0,0 +1,113 @@
+package registry
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ernestokarim/cb/config"
+	"github.com/kylelemons/go-gypsy/yaml"
+)
+
+func newConfig(t *testing.T, content string) *config.Config {
+	root, err := yaml.Parse(strings.NewReader(content))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return config.NewConfig(&yaml.File{Root: root})
+}
+
+func TestExpandPatterns(t *testing.T) {
+	c := newConfig(t, "paths:\n  base: app/base.js\nsass:\n  - source: a.scss\n  - source: styles/b.scss\n")
+	tests := []struct {
+		patterns []string
+		want     []string
+	}{
+		{[]string{"app/**/*.js"}, []string{"app/**/*.js"}},
+		{[]string{"temp/${paths.base}"}, []string{"temp/app/base.js"}},
+		{[]string{"temp/${paths.base:base}"}, []string{"temp/base.js"}},
+		{[]string{"app/${sass[].source}", "!x"}, []string{"app/a.scss", "app/styles/b.scss", "!x"}},
+		{[]string{"${sass[].source:base}.map"}, []string{"a.scss.map", "b.scss.map"}},
+		{[]string{"${missing}/*.js"}, []string{}},
+	}
+	for _, test := range tests {
+		if got := expandPatterns(c, test.patterns); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("expandPatterns(%q) = %q, want %q", test.patterns, got, test.want)
+		}
+	}
+
+	if got := expandPatterns(nil, []string{"a", "${paths.base}"}); !reflect.DeepEqual(got, []string{"a"}) {
+		t.Errorf("expandPatterns without config = %q, want [a]", got)
+	}
+}
+
+func TestFingerprint(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cb-fingerprint")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	input := filepath.Join(dir, "app.scss")
+	write := func(content string) {
+		if err := ioutil.WriteFile(input, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("a")
+
+	info := NewTask("fingerprint:a", 0, nil).Inputs(filepath.Join(dir, "*.scss")).Reads("[sass]")
+	other := NewTask("fingerprint:b", 0, nil).Inputs(filepath.Join(dir, "*.scss")).Reads("[sass]")
+	c := newConfig(t, "sass: a\npush: a\n")
+
+	fp := func(c *config.Config, info *Info, values map[string]string) string {
+		j := &job{name: info.Name, info: info}
+		if values != nil {
+			j.args = &Args{values: values, lists: map[string][]string{}}
+		}
+		result, err := fingerprint(context.Background(), c, j)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return result
+	}
+
+	base := fp(c, info, nil)
+	if again := fp(c, info, nil); again != base {
+		t.Errorf("the fingerprint should not change without changes: %s != %s", again, base)
+	}
+	if fp(c, other, nil) == base {
+		t.Errorf("the fingerprint should change with the task")
+	}
+	if fp(c, info, map[string]string{"name": "foo"}) == base {
+		t.Errorf("the fingerprint should change with the arguments")
+	}
+	if fp(newConfig(t, "sass: b\npush: a\n"), info, nil) == base {
+		t.Errorf("the fingerprint should change with the config keys read")
+	}
+	if fp(newConfig(t, "sass: a\npush: b\n"), info, nil) != base {
+		t.Errorf("the fingerprint should not change with other config keys")
+	}
+
+	write("b")
+	if fp(c, info, nil) == base {
+		t.Errorf("the fingerprint should change with the inputs")
+	}
+	write("a")
+	if fp(c, info, nil) != base {
+		t.Errorf("the fingerprint should only depend on the content of the inputs")
+	}
+
+	computed := NewTask("fingerprint:c", 0, nil).InputsFrom(func(c *config.Config) ([]string, error) {
+		return []string{filepath.Join(dir, "*.scss")}, nil
+	})
+	before := fp(c, computed, nil)
+	write("b")
+	if fp(c, computed, nil) == before {
+		t.Errorf("the fingerprint should change with the computed inputs")
+	}
+}
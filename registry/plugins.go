@@ -0,0 +1,152 @@
+package registry
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/ernestokarim/cb/config"
+	"github.com/ernestokarim/cb/utils"
+)
+
+// Tasks not compiled inside cb can be provided by external executables
+// named `cb-<task>`, placed in ~/.cb/plugins or in any folder of the PATH.
+// The plugin runs with the following protocol:
+//
+//   - The working directory is the folder of the config file (the
+//     current one if there is no config file).
+//   - The config values are written as a JSON object to its stdin
+//     (`null` if there is no config file).
+//   - The arguments of the task are passed as command line arguments:
+//     the inline ones (`task[a,b]`), the `--key=value` entries that follow
+//     it or, if there are none of them, the queue entries up to the next
+//     known task.
+//   - The CB_TASK environment variable contains the name of the task, and
+//     CB_VERBOSE & CB_DRY_RUN are set to "1" when those modes are enabled.
+//     Plugins run in dry-run mode too: they should print what they would
+//     do instead of changing anything.
+//   - A non-zero exit code makes the task fail.
+const pluginPrefix = "cb-"
+
+// findPlugin returns the path of the executable that implements a task.
+func findPlugin(name string) (string, error) {
+	file := pluginPrefix + name
+	if dir := pluginsPath(); dir != "" {
+		path := filepath.Join(dir, file)
+		if info, err := os.Stat(path); err == nil && isExecutable(info) {
+			return path, nil
+		}
+	}
+	return exec.LookPath(file)
+}
+
+func pluginsPath() string {
+	if p := config.GetUserConfigsPath(); p != "" {
+		return filepath.Join(p, "plugins")
+	}
+	return ""
+}
+
+func isExecutable(info os.FileInfo) bool {
+	return !info.IsDir() && info.Mode()&0111 != 0
+}
+
+// pluginNames returns the names of the tasks of all the plugins found.
+func pluginNames() []string {
+	dirs := filepath.SplitList(os.Getenv("PATH"))
+	if dir := pluginsPath(); dir != "" {
+		dirs = append(dirs, dir)
+	}
+
+	found := map[string]bool{}
+	names := []string{}
+	for _, dir := range dirs {
+		entries, err := ioutil.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			name := entry.Name()
+			if !strings.HasPrefix(name, pluginPrefix) || !isExecutable(entry) {
+				continue
+			}
+			name = name[len(pluginPrefix):]
+			if !found[name] && tasks[name] == nil {
+				found[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+func pluginTask(path string) Task {
+	return func(c *config.Config, q *Queue) error {
+		ctx := q.Context()
+
+		input := []byte("null")
+		if c != nil {
+			var err error
+			input, err = c.JSON()
+			if err != nil {
+				return fmt.Errorf("serialize config failed: %w", err)
+			}
+		}
+
+		cmd := exec.Command(path, q.Args().List("args")...)
+		cmd.Stdin = bytes.NewReader(input)
+		if c != nil {
+			cmd.Dir = c.Dir()
+		}
+		cmd.Env = append(os.Environ(), "CB_TASK="+q.CurTask)
+		if *config.Verbose {
+			cmd.Env = append(cmd.Env, "CB_VERBOSE=1")
+		}
+		if *config.DryRun {
+			cmd.Env = append(cmd.Env, "CB_DRY_RUN=1")
+		}
+		// Plugins run in dry-run mode too, skipping their own changes
+		if err := utils.ExecDryRunCommand(ctx, cmd); err != nil {
+			return fmt.Errorf("plugin failed: %w", err)
+		}
+		return nil
+	}
+}
+
+// IsPlugin returns true if the task is provided by an external plugin.
+func IsPlugin(name string) bool {
+	if tasks[name] != nil {
+		return false
+	}
+	_, err := findPlugin(name)
+	return err == nil
+}
+
+// isQueuedTask returns true if the queue entry references a registered
+// task. Plugins are not looked up: the entry could be an argument of the
+// previous task that happens to match an executable of the PATH.
+func isQueuedTask(t string) bool {
+	name, version, _, err := parseCall(t)
+	if err != nil {
+		return false
+	}
+	_, err = getTask(name, version)
+	return err == nil
+}
+
+// isTaskCall returns true if the entry, known to be in task position,
+// references a registered task or a plugin.
+func isTaskCall(t string) bool {
+	name, version, _, err := parseCall(t)
+	if err != nil {
+		return false
+	}
+	_, err = lookupTask(name, version)
+	return err == nil
+}
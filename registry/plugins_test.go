@@ -0,0 +1,48 @@
+package registry
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"testing"
+)
+
+func TestPluginPosition(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("plugins are found by their executable bit")
+	}
+	dir, err := ioutil.TempDir("", "cb-plugins")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	if err := ioutil.WriteFile(filepath.Join(dir, "cb-plugged"), []byte("#!/bin/sh\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Setenv("PATH", os.Getenv("PATH"))
+	os.Setenv("PATH", dir)
+
+	if !IsTask("plugged") {
+		t.Errorf("the plugin should be found in task position")
+	}
+
+	// Arguments that match a plugin are still arguments
+	q := &Queue{}
+	q.AddTasks([]string{"a", "plugged", "args:first"})
+	j := &job{name: "t", info: &Info{Name: "t", args: []string{"[args...]"}}}
+	args, err := q.takeArgs(j, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := args.List("args"); !reflect.DeepEqual(got, []string{"a", "plugged"}) {
+		t.Errorf("args = %v, want [a plugged]", got)
+	}
+
+	q = &Queue{}
+	q.AddTasks([]string{"plugged", "args:first"})
+	if got := q.Queued(); !reflect.DeepEqual(got, []string{"plugged", "args:first"}) {
+		t.Errorf("Queued() = %v, want [plugged args:first]", got)
+	}
+}
@@ -0,0 +1,220 @@
+package registry
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/ernestokarim/cb/events"
+	"github.com/ernestokarim/cb/utils"
+)
+
+// profile records the wall time of each task run by the queue, and
+// the child processes they launch.
+type profile struct {
+	mutex   sync.Mutex
+	start   time.Time
+	records []*record
+
+	// Lanes (rows of the trace viewer) used by the running tasks
+	lanes []bool
+}
+
+type record struct {
+	key      string
+	lane     int
+	start    time.Time
+	duration time.Duration
+	err      error
+	execs    []utils.Span
+
+	// The outputs were restored from a previous build
+	cached bool
+}
+
+func newProfile() *profile {
+	return &profile{start: time.Now()}
+}
+
+// begin starts the record of a task in the first free lane.
+func (p *profile) begin(key string) *record {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	lane := 0
+	for lane < len(p.lanes) && p.lanes[lane] {
+		lane++
+	}
+	if lane == len(p.lanes) {
+		p.lanes = append(p.lanes, true)
+	}
+	p.lanes[lane] = true
+
+	r := &record{key: key, lane: lane, start: time.Now()}
+	p.records = append(p.records, r)
+	return r
+}
+
+func (p *profile) end(r *record, err error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	r.duration = time.Since(r.start)
+	r.err = err
+	p.lanes[r.lane] = false
+}
+
+// tracer returns the function that records the commands of the task.
+func (p *profile) tracer(r *record) func(span utils.Span) {
+	return func(span utils.Span) {
+		p.mutex.Lock()
+		r.execs = append(r.execs, span)
+		p.mutex.Unlock()
+
+		e := &events.Event{
+			Type:     events.Exec,
+			Task:     r.key,
+			Command:  span.Name,
+			Duration: span.Duration.Seconds(),
+			Status:   "ok",
+		}
+		if span.Err != nil {
+			e.Status = "failed"
+			e.Message = span.Err.Error()
+		}
+		events.Emit(e)
+	}
+}
+
+// finishEvent emits the events of a finished task.
+func finishEvent(r *record, cancelled bool) {
+	e := &events.Event{
+		Type:     events.TaskFinished,
+		Task:     r.key,
+		Duration: r.duration.Seconds(),
+		Status:   "ok",
+	}
+	if r.err != nil {
+		e.Status = "failed"
+		if cancelled {
+			e.Status = "cancelled"
+		}
+		events.Fail(r.key, r.err)
+	} else if r.cached {
+		e.Status = "cached"
+	}
+	events.Emit(e)
+}
+
+// printSummary prints a table with the timings of the tasks, from the
+// slowest to the fastest one. Nothing is printed for a single task.
+func (p *profile) printSummary() {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if len(p.records) < 2 {
+		return
+	}
+
+	records := make([]*record, len(p.records))
+	copy(records, p.records)
+	sort.SliceStable(records, func(i, j int) bool {
+		return records[i].duration > records[j].duration
+	})
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "    TASK\tTIME\tEXEC\tCOMMANDS")
+	for _, r := range records {
+		var exec time.Duration
+		for _, span := range r.execs {
+			exec += span.Duration
+		}
+		key := r.key
+		if r.err != nil {
+			key += " (failed)"
+		} else if r.cached {
+			key += " (cached)"
+		}
+		fmt.Fprintf(w, "    %s\t%.3fs\t%.3fs\t%d\n", key, r.duration.Seconds(),
+			exec.Seconds(), len(r.execs))
+	}
+	fmt.Println()
+	w.Flush()
+	fmt.Println()
+}
+
+// traceEvent is the JSON representation of an event of the Chrome
+// trace-event format, that can be loaded in chrome://tracing.
+type traceEvent struct {
+	Name     string            `json:"name"`
+	Category string            `json:"cat,omitempty"`
+	Phase    string            `json:"ph"`
+	Time     int64             `json:"ts"`
+	Duration int64             `json:"dur,omitempty"`
+	Pid      int               `json:"pid"`
+	Tid      int               `json:"tid"`
+	Args     map[string]string `json:"args,omitempty"`
+}
+
+// write saves the trace of all the records to filename.
+func (p *profile) write(filename string) error {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	micros := func(t time.Time) int64 {
+		return int64(t.Sub(p.start) / time.Microsecond)
+	}
+
+	events := []*traceEvent{}
+	for lane := range p.lanes {
+		events = append(events, &traceEvent{
+			Name:  "thread_name",
+			Phase: "M",
+			Pid:   1,
+			Tid:   lane,
+			Args:  map[string]string{"name": fmt.Sprintf("job %d", lane)},
+		})
+	}
+	for _, r := range p.records {
+		ev := &traceEvent{
+			Name:     r.key,
+			Category: "task",
+			Phase:    "X",
+			Time:     micros(r.start),
+			Duration: int64(r.duration / time.Microsecond),
+			Pid:      1,
+			Tid:      r.lane,
+		}
+		if r.err != nil {
+			ev.Args = map[string]string{"error": r.err.Error()}
+		}
+		events = append(events, ev)
+
+		for _, span := range r.execs {
+			events = append(events, &traceEvent{
+				Name:     span.Name,
+				Category: "exec",
+				Phase:    "X",
+				Time:     micros(span.Start),
+				Duration: int64(span.Duration / time.Microsecond),
+				Pid:      1,
+				Tid:      r.lane,
+			})
+		}
+	}
+
+	content, err := json.MarshalIndent(map[string]interface{}{
+		"traceEvents":     events,
+		"displayTimeUnit": "ms",
+	}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal trace failed: %w", err)
+	}
+	if err := ioutil.WriteFile(filename, content, 0644); err != nil {
+		return fmt.Errorf("write trace failed: %w", err)
+	}
+	return nil
+}
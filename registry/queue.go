@@ -1,38 +1,157 @@
 package registry
 
 import (
+	"bytes"
+	"context"
 	"fmt"
 	"log"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/ernestokarim/cb/colors"
 	"github.com/ernestokarim/cb/config"
+	"github.com/ernestokarim/cb/events"
+	"github.com/ernestokarim/cb/utils"
 )
 
 // Queue of tasks to execute.
+// Each running task receives its own copy of the queue, sharing the list
+// of pending tasks with the rest of them.
 type Queue struct {
-	tasks   []string
+	*state
 	CurTask string
+
+	ctx      context.Context
+	args     *Args
+	cleanups []func() error
+
+	// Outputs of the running task found when running it
+	produced []string
+}
+
+// state is shared between all the copies of a queue.
+type state struct {
+	mutex sync.Mutex
+	tasks []string
+
+	// Tasks already executed, indexed by `name@version`
+	done map[string]bool
+
+	profile *profile
+
+	// Failures collected in keep-going mode, indexed by `name@version`
+	failed   map[string]bool
+	failures []*failure
+}
+
+// NewQueue creates an empty queue. Cancelling ctx (e.g. when receiving
+// a signal) stops the running tasks and their child processes.
+func NewQueue(ctx context.Context) *Queue {
+	q := &Queue{ctx: ctx}
+	q.init()
+	return q
+}
+
+func (q *Queue) init() {
+	if q.state == nil {
+		q.state = &state{
+			done:    map[string]bool{},
+			profile: newProfile(),
+			failed:  map[string]bool{},
+		}
+	}
+}
+
+// Context returns the context of the running task. It will be cancelled
+// if the task should stop before finishing its work.
+func (q *Queue) Context() context.Context {
+	if q.ctx == nil {
+		return context.Background()
+	}
+	return q.ctx
+}
+
+// WithContext returns a copy of the queue whose tasks run with ctx. It
+// shares the pending & executed tasks with the original one.
+func (q *Queue) WithContext(ctx context.Context) *Queue {
+	q.init()
+	return &Queue{
+		state:   q.state,
+		CurTask: q.CurTask,
+		ctx:     ctx,
+		args:    q.args,
+	}
+}
+
+// Args returns the arguments passed to the running task.
+func (q *Queue) Args() *Args {
+	return q.args
+}
+
+// OnCleanup registers a function to undo the partial work of the running
+// task. They are called in reverse order if the task fails or is cancelled.
+func (q *Queue) OnCleanup(f func() error) {
+	q.cleanups = append(q.cleanups, f)
+}
+
+// Produced declares files written by the running task that are not known
+// before running it, so they are restored with the rest of its outputs.
+func (q *Queue) Produced(paths ...string) {
+	q.produced = append(q.produced, paths...)
+}
+
+func (q *Queue) cleanup() {
+	for i := len(q.cleanups) - 1; i >= 0; i-- {
+		if err := q.cleanups[i](); err != nil {
+			utils.Logf(q.Context(), "%scleanup of %s failed: %s%s\n", colors.Red,
+				q.CurTask, err, colors.Reset)
+		}
+	}
+	q.cleanups = nil
 }
 
 // AddTask to the queue.
 func (q *Queue) AddTask(t string) {
+	q.init()
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
 	q.tasks = append(q.tasks, t)
 }
 
 // AddTasks take a list to add them to the queue.
 func (q *Queue) AddTasks(tasks []string) {
+	q.init()
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
 	q.tasks = append(q.tasks, tasks...)
 }
 
 // RunWithTimer executes all the tasks of the queue timing them at the
-// same time and printing the result at the end.
+// same time and printing the result at the end, with a summary of
+// the time spent by each one.
 func (q *Queue) RunWithTimer(c *config.Config) error {
 	start := time.Now()
-	if err := q.run(c); err != nil {
-		return fmt.Errorf("run queue failed: %s", err)
+	err := q.run(c)
+
+	if !events.Enabled() {
+		q.profile.printSummary()
+	}
+	if reportErr := q.printFailures(); reportErr != nil {
+		err = reportErr
+	}
+	if *config.Profile != "" {
+		if err := q.profile.write(*config.Profile); err != nil {
+			return fmt.Errorf("write profile failed: %w", err)
+		}
+	}
+
+	if q.Context().Err() != nil {
+		return fmt.Errorf("interrupted")
+	}
+	if err != nil {
+		return fmt.Errorf("run queue failed: %w", err)
 	}
 	log.Printf("%sFinished in %.3f seconds%s", colors.Green,
 		time.Since(start).Seconds(), colors.Reset)
@@ -41,19 +160,22 @@ func (q *Queue) RunWithTimer(c *config.Config) error {
 
 // RunTasks executes directly the tasks passed as argument.
 func (q *Queue) RunTasks(c *config.Config, tasks []string) error {
-	oldTask := q.CurTask
-
+	q.init()
+	q.mutex.Lock()
 	q.tasks = append(tasks, q.tasks...)
+	q.mutex.Unlock()
+
 	if err := q.run(c); err != nil {
-		return fmt.Errorf("run task failed: %s", err)
+		return fmt.Errorf("run task failed: %w", err)
 	}
-
-	q.CurTask = oldTask
 	return nil
 }
 
 // NextTask returns the name of the next task (aka a task argument).
 func (q *Queue) NextTask() string {
+	q.init()
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
 	if len(q.tasks) > 0 {
 		return q.tasks[0]
 	}
@@ -63,55 +185,434 @@ func (q *Queue) NextTask() string {
 // RemoveNextTask deletes the name of the next task from the queue. It should
 // be used with NextTask to find and remove task arguments from the command line.
 func (q *Queue) RemoveNextTask() {
-	if q.NextTask() != "" {
+	q.init()
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	if len(q.tasks) > 0 {
 		q.tasks = q.tasks[1:]
 	}
 }
 
+// Queued returns the pending tasks of the queue, without the entries that
+// will be taken as their arguments. Entries that are not tasks are ignored.
+// The queue itself is not modified.
+func (q *Queue) Queued() []string {
+	q.init()
+	q.mutex.Lock()
+	sim := &Queue{state: &state{tasks: append([]string{}, q.tasks...)}}
+	q.mutex.Unlock()
+
+	queued := []string{}
+	for {
+		t, ok := sim.pop()
+		if !ok {
+			break
+		}
+		task, version, inline, err := parseCall(t)
+		if err != nil {
+			continue
+		}
+		info, err := lookupTask(task, version)
+		if err != nil {
+			continue
+		}
+		queued = append(queued, t)
+		sim.takeArgs(&job{name: task, info: info}, inline)
+	}
+	return queued
+}
+
+// pop extracts the first pending task of the queue.
+func (q *Queue) pop() (string, bool) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	if len(q.tasks) == 0 {
+		return "", false
+	}
+	var t string
+	t, q.tasks = q.tasks[0], q.tasks[1:]
+	return t, true
+}
+
+func (q *Queue) pending() int {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	return len(q.tasks)
+}
+
 // Run executes all the tasks of the queue without timing them or printing anything.
+// In keep-going mode it runs all of them, returning an error at the end if
+// any of them failed.
 func (q *Queue) run(c *config.Config) error {
-	for len(q.tasks) > 0 {
-		var t string
-		t, q.tasks = q.tasks[0], q.tasks[1:]
+	q.init()
+	var failure error
+	for {
+		t, ok := q.pop()
+		if !ok {
+			break
+		}
+		if err := q.runTask(c, t); err != nil {
+			if !*config.KeepGoing || q.Context().Err() != nil {
+				return err
+			}
+			if err != errTasksFailed {
+				q.addFailure(t, err, false)
+			}
+			failure = errTasksFailed
+		}
+	}
+	return failure
+}
+
+// runTask executes a task and the prerequisites it needs.
+func (q *Queue) runTask(c *config.Config, t string) error {
+	task, version, inline, err := parseCall(t)
+	if err != nil {
+		return err
+	}
+
+	plan, err := q.resolve(task, version)
+	if err != nil {
+		return fmt.Errorf("resolve dependencies failed (%s): %w", t, err)
+	}
 
-		var task string
-		var version int
-		if strings.Contains(t, "@") {
-			parts := strings.Split(t, "@")
-			if len(parts) != 2 {
-				return fmt.Errorf("task should have the `name@version` "+
-					"format: %+v", parts)
+	requested := plan[len(plan)-1]
+	requested.args, err = q.takeArgs(requested, inline)
+	if err != nil {
+		return err
+	}
+
+	return q.execute(c, plan)
+}
+
+// execute runs the plan, starting each task as soon as all its
+// prerequisites have finished. Up to *config.Jobs tasks will run
+// at the same time, buffering their output until they finish.
+// When a task fails the rest of them are cancelled, except in keep-going
+// mode where only the tasks that depend on it are skipped.
+//
+// In dry-run mode tasks run one by one, and their failures are only
+// reported, as they will probably miss the results of the previous ones.
+func (q *Queue) execute(c *config.Config, plan []*job) error {
+	jobs := *config.Jobs
+	if jobs < 1 || *config.DryRun {
+		jobs = 1
+	}
+
+	ctx, cancel := context.WithCancel(q.Context())
+	defer cancel()
+
+	type result struct {
+		j   *job
+		out *buffer
+		err error
+	}
+	results := make(chan *result)
+
+	finished := map[*job]bool{}
+	started := map[*job]bool{}
+	running := 0
+	var failure error
+
+	// Tasks that failed in a previous plan block the ones depending on them
+	if *config.KeepGoing {
+		for _, j := range plan {
+			if q.isFailed(j.key()) {
+				started[j] = true
+				failure = errTasksFailed
 			}
+		}
+	}
 
-			v, err := strconv.ParseInt(parts[1], 10, 32)
-			if err != nil {
-				return fmt.Errorf("parse version failed (%s): %s", parts[1], err)
+	for {
+		// Start all the tasks that are ready to run
+		for _, j := range plan {
+			if (failure != nil && !*config.KeepGoing) || running >= jobs {
+				break
+			}
+			if ctx.Err() != nil {
+				break
 			}
+			if started[j] || !j.ready(finished) {
+				continue
+			}
+			started[j] = true
+			running++
+
+			if *config.Verbose || *config.DryRun {
+				log.Printf("%s[%2d] Running %s...%s\n",
+					colors.Cyan, q.pending(), j.key(), colors.Reset)
+			} else {
+				log.Printf("%s[%2d] Running %s%s\n",
+					colors.Cyan, q.pending(), j.name, colors.Reset)
+			}
+			events.Emit(&events.Event{Type: events.TaskStarted, Task: j.key()})
+
+			r := &result{j: j}
+			taskCtx := ctx
+			if jobs > 1 {
+				r.out = &buffer{}
+				taskCtx = utils.WithOutput(ctx, r.out)
+			}
+			rec := q.profile.begin(j.key())
+			view := &Queue{
+				state:   q.state,
+				CurTask: j.name,
+				ctx:     utils.WithTrace(taskCtx, q.profile.tracer(rec)),
+				args:    j.args,
+			}
+			go func() {
+				r.err = q.call(c, j, view, rec)
+				if r.err == nil && ctx.Err() != nil {
+					r.err = ctx.Err()
+				}
+				if r.err != nil {
+					view.cleanup()
+				}
+				q.profile.end(rec, r.err)
+				finishEvent(rec, ctx.Err() != nil)
+				results <- r
+			}()
+		}
+		if running == 0 {
+			break
+		}
+
+		// Wait for the next task to finish
+		r := <-results
+		running--
+		if r.out != nil {
+			r.out.flush()
+		}
 
-			task = parts[0]
-			version = int(v)
-		} else {
-			task = t
-			version = -1
+		if r.err != nil && *config.DryRun {
+			utils.Warningf(ctx, "task cannot be fully simulated (%s): %s", r.j.key(), r.err)
+		} else if r.err != nil && *config.KeepGoing {
+			log.Printf("%stask failed (%s): %s%s\n", colors.Red, r.j.key(),
+				r.err, colors.Reset)
+			q.addFailure(r.j.key(), r.err, false)
+			failure = errTasksFailed
+			continue
+		} else if r.err != nil {
+			finished[r.j] = true
+			if failure == nil {
+				failure = fmt.Errorf("task failed (%s): %w", r.j.key(), r.err)
+				cancel()
+			} else if *config.Verbose {
+				utils.Warningf(ctx, "cancelled task failed (%s): %s", r.j.key(), r.err)
+			}
+			continue
+		}
+		finished[r.j] = true
+		q.markDone(r.j)
+	}
+
+	if failure == nil && ctx.Err() != nil {
+		failure = ctx.Err()
+	}
+	if *config.KeepGoing && ctx.Err() == nil {
+		for _, j := range plan {
+			if !started[j] {
+				q.addFailure(j.key(), fmt.Errorf("skipped, a prerequisite failed"), true)
+			}
 		}
+	}
+
+	return failure
+}
+
+// buffer stores the output of a task until it finishes. It's safe to
+// use from several goroutines (e.g. stdout & stderr of a command).
+type buffer struct {
+	mutex sync.Mutex
+	buf   bytes.Buffer
+}
+
+func (b *buffer) Write(p []byte) (int, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *buffer) flush() {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.buf.WriteTo(utils.Output(context.Background()))
+}
+
+// job is a task ready to be executed by the queue.
+type job struct {
+	// Name of the task as it was requested (greedy tasks share the info)
+	name string
+	info *Info
+
+	// Prerequisites of the job inside the same plan
+	deps []*job
+
+	// Arguments, only for the requested task
+	args *Args
+}
+
+func (j *job) key() string {
+	return fmt.Sprintf("%s@%d", j.name, j.info.Version)
+}
+
+// dependsOn returns true if the job needs other before running, directly
+// or through its prerequisites.
+func (j *job) dependsOn(other *job) bool {
+	for _, dep := range j.deps {
+		if dep == other || dep.dependsOn(other) {
+			return true
+		}
+	}
+	return false
+}
+
+func (j *job) ready(finished map[*job]bool) bool {
+	for _, dep := range j.deps {
+		if !finished[dep] {
+			return false
+		}
+	}
+	return true
+}
 
-		f, err := getTask(task, version)
+func (q *Queue) markDone(j *job) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	q.done[j.key()] = true
+}
+
+func (q *Queue) isDone(key string) bool {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	return q.done[key]
+}
+
+// resolve returns the list of tasks that should be executed, in order,
+// to run the requested one. Prerequisites already executed by this queue
+// are ignored; the requested task is always the last one of the list.
+func (q *Queue) resolve(task string, version int) ([]*job, error) {
+	plan := []*job{}
+	visited := map[string]*job{}
+	path := []string{}
+
+	var visit func(task string, version int, requested bool) (*job, error)
+	visit = func(task string, version int, requested bool) (*job, error) {
+		info, err := lookupTask(task, version)
 		if err != nil {
-			return err
+			return nil, err
 		}
+		j := &job{name: task, info: info}
+		key := j.key()
 
-		if *config.Verbose {
-			log.Printf("%s[%2d] Running %s@%d...%s\n",
-				colors.Cyan, len(q.tasks), task, version, colors.Reset)
-		} else {
-			log.Printf("%s[%2d] Running %s%s\n",
-				colors.Cyan, len(q.tasks), task, colors.Reset)
+		for i, p := range path {
+			if p == key {
+				cycle := append(path[i:], key)
+				return nil, fmt.Errorf("dependency cycle detected: %s",
+					strings.Join(cycle, " -> "))
+			}
+		}
+		if visited[key] != nil {
+			return visited[key], nil
+		}
+		if !requested && q.isDone(key) {
+			return nil, nil
 		}
 
-		q.CurTask = task
-		if err := f(c, q); err != nil {
-			return fmt.Errorf("task failed (%s): %s", t, err)
+		path = append(path, key)
+		visitDep := func(dep string) (*job, error) {
+			name, v, inline, err := parseCall(dep)
+			if err != nil {
+				return nil, fmt.Errorf("bad dependency of %s: %w", key, err)
+			}
+			d, err := visit(name, v, false)
+			if err != nil || d == nil {
+				return nil, err
+			}
+			if inline != nil || d.args == nil {
+				// Only the inline arguments, the queue ones belong to other
+				// tasks; the required ones are checked even if there are none
+				d.args, err = (&Queue{}).takeArgs(d, append([]string{}, inline...))
+				if err != nil {
+					return nil, fmt.Errorf("bad dependency of %s: %w", key, err)
+				}
+			}
+			j.deps = append(j.deps, d)
+			return d, nil
+		}
+		first := []*job{}
+		for _, dep := range info.first {
+			d, err := visitDep(dep)
+			if err != nil {
+				return nil, err
+			}
+			if d != nil {
+				first = append(first, d)
+			}
+		}
+		before := len(plan)
+		for _, dep := range info.deps {
+			if _, err := visitDep(dep); err != nil {
+				return nil, err
+			}
 		}
+
+		// Each step waits for the previous one, unless it was
+		// already needed by it
+		var prev *job
+		for _, step := range info.steps {
+			d, err := visitDep(step)
+			if err != nil {
+				return nil, err
+			}
+			if d == nil || d == prev {
+				continue
+			}
+			if prev != nil && !prev.dependsOn(d) {
+				d.deps = append(d.deps, prev)
+			}
+			prev = d
+		}
+
+		// The jobs added after the first ones wait for them
+		for _, added := range plan[before:] {
+			for _, f := range first {
+				if !added.dependsOn(f) {
+					added.deps = append(added.deps, f)
+				}
+			}
+		}
+		path = path[:len(path)-1]
+
+		visited[key] = j
+		plan = append(plan, j)
+		return j, nil
 	}
-	return nil
+	if _, err := visit(task, version, true); err != nil {
+		return nil, err
+	}
+
+	return plan, nil
+}
+
+// parseTask extracts the name and the version of a task from the
+// `name@version` format. If no version is present it returns -1.
+func parseTask(t string) (string, int, error) {
+	if !strings.Contains(t, "@") {
+		return t, -1, nil
+	}
+
+	parts := strings.Split(t, "@")
+	if len(parts) != 2 {
+		return "", 0, fmt.Errorf("task should have the `name@version` "+
+			"format: %+v", parts)
+	}
+
+	v, err := strconv.ParseInt(parts[1], 10, 32)
+	if err != nil {
+		return "", 0, fmt.Errorf("parse version failed (%s): %w", parts[1], err)
+	}
+
+	return parts[0], int(v), nil
 }
@@ -0,0 +1,184 @@
+package registry
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestQueued(t *testing.T) {
+	NewTask("queued:args", 0, nil).Args("<name>", "[module]")
+	NewTask("queued:tasks", 0, nil).Args("[tasks:task...]")
+
+	tests := []struct {
+		queue []string
+		want  []string
+	}{
+		{[]string{"args:first", "args:second"}, []string{"args:first", "args:second"}},
+		{[]string{"queued:args", "foo", "bar", "args:first"}, []string{"queued:args", "args:first"}},
+		{[]string{"queued:args", "--name=foo", "args:first"}, []string{"queued:args", "args:first"}},
+		{[]string{"queued:args[foo]", "args:first"}, []string{"queued:args[foo]", "args:first"}},
+		{[]string{"queued:tasks", "args:first", "args:second"}, []string{"queued:tasks"}},
+		{[]string{"missing", "args:first"}, []string{"args:first"}},
+	}
+	for _, test := range tests {
+		q := &Queue{}
+		q.AddTasks(test.queue)
+		if got := q.Queued(); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("Queued() of %v = %v, want %v", test.queue, got, test.want)
+		}
+		if !reflect.DeepEqual(q.tasks, test.queue) {
+			t.Errorf("Queued() modified the queue: %v", q.tasks)
+		}
+	}
+}
+
+func TestResolveSteps(t *testing.T) {
+	NewTask("steps:a", 0, nil)
+	NewTask("steps:b", 0, nil)
+	NewTask("steps:c", 0, nil).Requires("steps:a")
+	NewTask("steps:args", 0, nil).Args("<name>")
+	NewTask("steps:alias", 0, nil).Requires("steps:b").steps = []string{"steps:c", "steps:b", "steps:a", "steps:args[foo]"}
+
+	q := &Queue{}
+	q.init()
+	plan, err := q.resolve("steps:alias", -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	jobs := map[string]*job{}
+	for _, j := range plan {
+		jobs[j.name] = j
+	}
+
+	if !jobs["steps:b"].dependsOn(jobs["steps:c"]) {
+		t.Errorf("steps:b should wait for steps:c")
+	}
+	// b already needs a through c, so a can't wait for b
+	if jobs["steps:a"].dependsOn(jobs["steps:b"]) {
+		t.Errorf("steps:a should not wait for steps:b")
+	}
+	if !jobs["steps:args"].dependsOn(jobs["steps:a"]) {
+		t.Errorf("steps:args should wait for steps:a")
+	}
+	if name := jobs["steps:args"].args.String("name"); name != "foo" {
+		t.Errorf("steps:args should receive its inline arguments, got %q", name)
+	}
+	if last := plan[len(plan)-1]; last.name != "steps:alias" || len(last.deps) != 5 {
+		t.Errorf("steps:alias should be the last job, with all the steps as deps: %+v", last)
+	}
+}
+
+func TestResolve(t *testing.T) {
+	NewTask("resolve:a", 0, nil)
+	NewTask("resolve:b", 0, nil).Requires("resolve:a")
+	NewTask("resolve:c", 0, nil).Requires("resolve:a", "resolve:b")
+	NewTask("resolve:d", 0, nil).Requires("resolve:c", "resolve:b")
+	NewTask("resolve:cycle1", 0, nil).Requires("resolve:cycle2")
+	NewTask("resolve:cycle2", 0, nil).Requires("resolve:a", "resolve:cycle1")
+	NewTask("resolve:self", 0, nil).Requires("resolve:self")
+	NewTask("resolve:missing", 0, nil).Requires("resolve:nope")
+	NewTask("resolve:named", 0, nil).Args("<name>")
+	NewTask("resolve:unnamed", 0, nil).Requires("resolve:named")
+	NewTask("resolve:inline", 0, nil).Requires("resolve:named[foo]")
+
+	tests := []struct {
+		task  string
+		done  []string
+		plan  []string
+		fails bool
+	}{
+		{task: "resolve:a", plan: []string{"resolve:a"}},
+		{task: "resolve:b", plan: []string{"resolve:a", "resolve:b"}},
+		{
+			// Shared prerequisites run once
+			task: "resolve:d",
+			plan: []string{"resolve:a", "resolve:b", "resolve:c", "resolve:d"},
+		},
+		{
+			// Executed prerequisites are skipped
+			task: "resolve:d",
+			done: []string{"resolve:a@0", "resolve:b@0"},
+			plan: []string{"resolve:c", "resolve:d"},
+		},
+		{
+			// The requested task runs again
+			task: "resolve:b",
+			done: []string{"resolve:a@0", "resolve:b@0"},
+			plan: []string{"resolve:b"},
+		},
+		{task: "resolve:cycle1", fails: true},
+		{task: "resolve:self", fails: true},
+		{task: "resolve:missing", fails: true},
+		{task: "resolve:nope", fails: true},
+		{
+			// The arguments of the prerequisites are checked too
+			task:  "resolve:unnamed",
+			fails: true,
+		},
+		{task: "resolve:inline", plan: []string{"resolve:named", "resolve:inline"}},
+	}
+	for _, test := range tests {
+		q := &Queue{}
+		q.init()
+		for _, key := range test.done {
+			q.done[key] = true
+		}
+
+		plan, err := q.resolve(test.task, -1)
+		if test.fails {
+			if err == nil {
+				t.Errorf("resolve(%s) should fail", test.task)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("resolve(%s) failed: %s", test.task, err)
+			continue
+		}
+		names := []string{}
+		for _, j := range plan {
+			names = append(names, j.name)
+		}
+		if !reflect.DeepEqual(names, test.plan) {
+			t.Errorf("resolve(%s) with %v done = %v, want %v", test.task, test.done, names, test.plan)
+		}
+	}
+
+	q := &Queue{}
+	q.init()
+	_, err := q.resolve("resolve:cycle1", -1)
+	want := "dependency cycle detected: resolve:cycle1@0 -> resolve:cycle2@0 -> resolve:cycle1@0"
+	if err == nil || err.Error() != want {
+		t.Errorf("resolve(resolve:cycle1) = %v, want %q", err, want)
+	}
+}
+
+func TestResolveFirst(t *testing.T) {
+	NewTask("first:check", 0, nil)
+	NewTask("first:clean", 0, nil)
+	NewTask("first:copy", 0, nil).Requires("first:clean")
+	NewTask("first:build", 0, nil).RequiresFirst("first:check").Requires("first:copy")
+
+	q := &Queue{}
+	q.init()
+	plan, err := q.resolve("first:build", -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	jobs := map[string]*job{}
+	names := []string{}
+	for _, j := range plan {
+		jobs[j.name] = j
+		names = append(names, j.name)
+	}
+
+	want := []string{"first:check", "first:clean", "first:copy", "first:build"}
+	if !reflect.DeepEqual(names, want) {
+		t.Errorf("plan = %v, want %v", names, want)
+	}
+	for _, name := range []string{"first:clean", "first:copy", "first:build"} {
+		if !jobs[name].dependsOn(jobs["first:check"]) {
+			t.Errorf("%s should wait for first:check", name)
+		}
+	}
+}
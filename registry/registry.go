@@ -2,8 +2,10 @@ package registry
 
 import (
 	"fmt"
+	"io"
 	"sort"
 	"strings"
+	"text/tabwriter"
 
 	"github.com/ernestokarim/cb/config"
 )
@@ -12,33 +14,144 @@ import (
 // to appear in the executable list.
 type Task func(c *config.Config, q *Queue) error
 
+// Info stores a registered version of a task and its metadata.
+type Info struct {
+	Name    string
+	Version int
+
+	f    Task
+	deps []string
+
+	// Prerequisites that run before everything else the task needs
+	first []string
+
+	// Prerequisites that run one after another, after the deps
+	steps []string
+
+	// Metadata shown in the usage of the task
+	desc    string
+	args    []string
+	configs []config.Key
+
+	// Files & tools that determine the result of the task
+	inputs     []string
+	inputsFrom []InputsFunc
+	outputs    []string
+	tools      [][]string
+}
+
 var (
-	tasks     = map[string]map[int]Task{}
+	tasks     = map[string]map[int]*Info{}
 	userTasks = map[string]bool{}
 )
 
-// NewTask registers a new task in the system
-func NewTask(name string, version int, f Task) {
+// NewTask registers a new task in the system. The returned info can be
+// used to declare additional metadata of the task.
+func NewTask(name string, version int, f Task) *Info {
 	m := tasks[name]
 	if m == nil {
-		m = map[int]Task{}
+		m = map[int]*Info{}
 	}
 	if m[version] != nil {
 		panic("task already registered: " + name)
 	}
 
-	m[version] = f
+	info := &Info{
+		Name:    name,
+		Version: version,
+		f:       f,
+	}
+	m[version] = info
 	tasks[name] = m
+
+	return info
 }
 
 // NewUserTask creates a new task intended for users, so they can call it from console
-func NewUserTask(name string, version int, f Task) {
+func NewUserTask(name string, version int, f Task) *Info {
 	userTasks[name] = true
-	NewTask(name, version, f)
+	return NewTask(name, version, f)
+}
+
+// Requires declares the tasks (`name`, `name@version` or `name[args]`) that
+// should run before this one. The queue resolves them, running each one
+// only once.
+func (info *Info) Requires(deps ...string) *Info {
+	info.deps = append(info.deps, deps...)
+	return info
+}
+
+// RequiresFirst declares prerequisites that should run before all the other
+// ones, even in parallel mode, e.g. the ones that ask the user something
+// or can stop the process.
+func (info *Info) RequiresFirst(deps ...string) *Info {
+	info.first = append(info.first, deps...)
+	return info
+}
+
+// Describe sets a short description of what the task does.
+func (info *Info) Describe(desc string) *Info {
+	info.desc = desc
+	return info
+}
+
+// Args declares the arguments of the task, in order. Required ones are
+// written as `<name>` and optional ones as `[name]`, after the required ones.
+// The queue extracts them before running the task; see Args for the syntax.
+func (info *Info) Args(args ...string) *Info {
+	info.args = append(info.args, args...)
+	return info
+}
+
+// Reads declares the config keys the task uses, with the same format
+// of the arguments: `<path:kind>` (required) or `[path:kind]` (optional).
+// Items of a list are written as `list[].key`. The config file is
+// validated against them before running the tasks.
+func (info *Info) Reads(keys ...string) *Info {
+	info.configs = append(info.configs, mustParseKeys(keys...)...)
+	return info
+}
+
+// Inputs declares the files the task reads, as walker patterns (see
+// utils.Walker). Values of the config can be used with `${path}`, e.g.
+// `${sass[].source}` for each item of a list, or `${paths.base:base}` for the
+// name of the file without its folders. Tasks with inputs are skipped
+// if neither them, the config keys read or the tool have changed since
+// the last build, restoring the outputs it produced then.
+func (info *Info) Inputs(patterns ...string) *Info {
+	info.inputs = append(info.inputs, patterns...)
+	return info
+}
+
+// InputsFunc returns the input patterns of a task computed from the config.
+type InputsFunc func(c *config.Config) ([]string, error)
+
+// InputsFrom declares inputs like Inputs, for the tasks whose files depend
+// on several keys of the config at once. The function is only called if
+// there is a config file.
+func (info *Info) InputsFrom(f InputsFunc) *Info {
+	info.inputsFrom = append(info.inputsFrom, f)
+	return info
+}
+
+// Outputs declares the files & folders written by the task, with the same
+// format of the inputs. See Queue.Produced for the ones only known
+// when running it.
+func (info *Info) Outputs(patterns ...string) *Info {
+	info.outputs = append(info.outputs, patterns...)
+	return info
+}
+
+// Tool declares the command that prints the version of an external tool
+// used by the task, e.g. `sass --version`. Upgrading it invalidates the
+// previous outputs.
+func (info *Info) Tool(command string, args ...string) *Info {
+	info.tools = append(info.tools, append([]string{command}, args...))
+	return info
 }
 
-// PrintTasks act as helper for the usage string printing all known tasks.
-func PrintTasks() {
+// PrintTasks act as helper for the usage string printing all known tasks to w.
+func PrintTasks(out io.Writer) {
 	system := []string{}
 	user := []string{}
 	for name, _ := range tasks {
@@ -51,21 +164,55 @@ func PrintTasks() {
 	sort.Strings(system)
 	sort.Strings(user)
 
-	fmt.Println("\n * USER TASKS:", strings.Join(user, ", "))
-	fmt.Println("\n * SYSTEM TASKS:", strings.Join(system, ", "))
-	fmt.Println()
+	fmt.Fprintln(out, "\n * USER TASKS:")
+	w := tabwriter.NewWriter(out, 0, 8, 2, ' ', 0)
+	for _, name := range user {
+		info, _ := getTask(name, -1)
+		fmt.Fprintf(w, "    %s\t%s\n", name, info.desc)
+	}
+	w.Flush()
+
+	fmt.Fprintln(out, "\n * SYSTEM TASKS:", strings.Join(system, ", "))
+	if plugins := pluginNames(); len(plugins) > 0 {
+		fmt.Fprintln(out, "\n * PLUGIN TASKS:", strings.Join(plugins, ", "))
+	}
+	fmt.Fprintln(out)
+}
+
+// IsTask returns true if there is a task registered with that name, or
+// a plugin that provides it.
+func IsTask(name string) bool {
+	_, err := lookupTask(name, -1)
+	return err == nil
+}
+
+// lookupTask works like getTask, falling back to the external plugins.
+// It should only be used for the names in task position, an argument of
+// another task could match any executable of the PATH.
+func lookupTask(name string, version int) (*Info, error) {
+	if tasks[name] != nil || greedyTask(name) != nil {
+		return getTask(name, version)
+	}
+
+	path, err := findPlugin(name)
+	if err != nil {
+		return nil, fmt.Errorf("task not found: %s", name)
+	}
+	if version > 0 {
+		return nil, fmt.Errorf("version not found: %d", version)
+	}
+	info := &Info{Name: name, f: pluginTask(path)}
+	info.Args("[args...]")
+	return info, nil
 }
 
 // Obtain the task by name and version. If version is -1 it will return the
 // latest version of that task.
 // It tries to retrieve a greedy task name:* too.
-func getTask(name string, version int) (Task, error) {
+func getTask(name string, version int) (*Info, error) {
 	m := tasks[name]
 	if m == nil {
-		if strings.Contains(name, ":") {
-			parts := strings.Split(name, ":")
-			m = tasks[parts[0]+":*"]
-		}
+		m = greedyTask(name)
 		if m == nil {
 			return nil, fmt.Errorf("task not found: %s", name)
 		}
@@ -79,10 +226,20 @@ func getTask(name string, version int) (Task, error) {
 		}
 	}
 
-	f := m[version]
-	if f == nil {
+	info := m[version]
+	if info == nil {
 		return nil, fmt.Errorf("version not found: %d", version)
 	}
 
-	return f, nil
+	return info, nil
+}
+
+// greedyTask returns the versions of the `name:*` task that handles the
+// name, if any.
+func greedyTask(name string) map[int]*Info {
+	if !strings.Contains(name, ":") {
+		return nil
+	}
+	parts := strings.Split(name, ":")
+	return tasks[parts[0]+":*"]
 }
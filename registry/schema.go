@@ -0,0 +1,76 @@
+package registry
+
+import (
+	"github.com/ernestokarim/cb/config"
+)
+
+// Config keys read by cb itself and not by a specific task.
+var globalConfigs = mustParseKeys(
+	"[tasks:list]",
+	"<tasks[].name>",
+	"[tasks[].description]",
+	"[tasks[].tasks:list]",
+	"[tasks[].command]",
+	"[tasks[].args:list]",
+	"[tasks[].dir]",
+	"[tasks[].env:list]",
+	"[tasks[].requires:list]",
+)
+
+func mustParseKeys(specs ...string) []config.Key {
+	keys := []config.Key{}
+	for _, spec := range specs {
+		key, err := config.ParseKey(spec)
+		if err != nil {
+			panic(err)
+		}
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// CheckConfig validates the config file against the keys declared by all
+// the tasks. The required keys are only checked for the listed tasks and
+// their prerequisites; names that are not tasks are ignored.
+func CheckConfig(c *config.Config, names []string) []*config.Problem {
+	known := append([]config.Key{}, globalConfigs...)
+	for _, versions := range tasks {
+		for _, info := range versions {
+			known = append(known, info.configs...)
+		}
+	}
+
+	required := append([]config.Key{}, globalConfigs...)
+	q := &Queue{}
+	q.init()
+	for _, name := range names {
+		task, version, _, err := parseCall(name)
+		if err != nil {
+			continue
+		}
+		plan, err := q.resolve(task, version)
+		if err != nil {
+			continue
+		}
+		for _, j := range plan {
+			required = append(required, j.info.configs...)
+		}
+	}
+
+	return c.Check(known, required)
+}
+
+// TaskConfigs returns the config keys declared by the latest version of
+// each task, indexed by its name. Tasks that don't read the config file
+// are not included.
+func TaskConfigs() map[string][]config.Key {
+	configs := map[string][]config.Key{}
+	for name := range tasks {
+		info, err := getTask(name, -1)
+		if err != nil || len(info.configs) == 0 {
+			continue
+		}
+		configs[name] = info.configs
+	}
+	return configs
+}
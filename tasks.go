@@ -8,9 +8,11 @@ import (
 	_ "github.com/ernestokarim/cb/tasks/clean/v0"
 	_ "github.com/ernestokarim/cb/tasks/compilejs/v0"
 	_ "github.com/ernestokarim/cb/tasks/concat/v0"
+	_ "github.com/ernestokarim/cb/tasks/config/v0"
 	_ "github.com/ernestokarim/cb/tasks/deploy/v0"
 	_ "github.com/ernestokarim/cb/tasks/dist/v0"
 	_ "github.com/ernestokarim/cb/tasks/form/v0"
+	_ "github.com/ernestokarim/cb/tasks/help/v0"
 	_ "github.com/ernestokarim/cb/tasks/htmlmin/v0"
 	_ "github.com/ernestokarim/cb/tasks/imagemin/v0"
 	_ "github.com/ernestokarim/cb/tasks/init/v0"
@@ -2,8 +2,9 @@ package v0
 
 import (
 	"bufio"
+	"context"
 	"fmt"
-	"log"
+	"io"
 	"os"
 	"path/filepath"
 	"strings"
@@ -23,74 +24,71 @@ var (
 )
 
 func init() {
-	registry.NewUserTask("angular:service", 0, service)
-	registry.NewUserTask("angular:controller", 0, controller)
-	registry.NewUserTask("angular:controllernv", 0, controller_noview)
+	registry.NewUserTask("angular:service", 0, service).
+		Describe("create a service and its test").
+		Args("<name>", "<module>")
+	registry.NewUserTask("angular:controller", 0, controller).
+		Describe("create a controller, its test & view, and add its route").
+		Args("<name>", "<module>", "[route]").
+		Reads("[paths.app=app/scripts/app.js]")
+	registry.NewUserTask("angular:controllernv", 0, controller_noview).
+		Describe("create a controller without view, its test and its route").
+		Args("<name>", "<module>", "[route]").
+		Reads("[paths.app=app/scripts/app.js]")
 }
 
 func service(c *config.Config, q *registry.Queue) error {
-	name := q.NextTask()
-	if name == "" {
-		return fmt.Errorf("first arg should be the name of the service")
-	}
-	q.RemoveNextTask()
-	module := q.NextTask()
-	if module == "" {
-		return fmt.Errorf("second arg should be the module of the service")
-	}
-	q.RemoveNextTask()
+	ctx := q.Context()
+	name := q.Args().String("name")
+	module := q.Args().String("module")
 
 	data := &serviceData{
 		Name:     name,
 		Module:   module,
 		Filename: filepath.Join(strings.Split(module, ".")...),
 	}
-	if err := writeServiceFile(data); err != nil {
-		return fmt.Errorf("write service failed: %s", err)
+	if err := writeServiceFile(ctx, data); err != nil {
+		return fmt.Errorf("write service failed: %w", err)
 	}
-	if err := writeServiceTestFile(data); err != nil {
-		return fmt.Errorf("write service test failed: %s", err)
+	if err := writeServiceTestFile(ctx, data); err != nil {
+		return fmt.Errorf("write service test failed: %w", err)
 	}
 
 	return nil
 }
 
 func controller(c *config.Config, q *registry.Queue) error {
-	name := q.NextTask()
-	if name == "" {
-		return fmt.Errorf("first arg should be the name of the controller")
-	}
-	q.RemoveNextTask()
+	ctx := q.Context()
+	name := q.Args().String("name")
 	if !strings.Contains(name, "Ctrl") {
 		name = name + "Ctrl"
 	}
-	module := q.NextTask()
-	if module == "" {
-		return fmt.Errorf("second arg should be the module of the controller")
+	module := q.Args().String("module")
+	route := q.Args().String("route")
+	appPath, err := c.GetDefault("paths.app", filepath.Join("app", "scripts", "app.js"))
+	if err != nil {
+		return err
 	}
-	q.RemoveNextTask()
-	route := q.NextTask()
-	q.RemoveNextTask()
 
 	data := &controllerData{
 		Name:     name,
 		Module:   module,
 		Route:    route,
 		Filename: filepath.Join(strings.Split(module, ".")...),
-		AppPath:  c.GetDefault("paths.app", filepath.Join("app", "scripts", "app.js")),
+		AppPath:  appPath,
 	}
-	if err := writeControllerFile(data); err != nil {
-		return fmt.Errorf("write controller failed: %s", err)
+	if err := writeControllerFile(ctx, data); err != nil {
+		return fmt.Errorf("write controller failed: %w", err)
 	}
-	if err := writeControllerTestFile(data); err != nil {
-		return fmt.Errorf("write controller test failed: %s", err)
+	if err := writeControllerTestFile(ctx, data); err != nil {
+		return fmt.Errorf("write controller test failed: %w", err)
 	}
-	if err := writeControllerViewFile(data); err != nil {
-		return fmt.Errorf("write view failed: %s", err)
+	if err := writeControllerViewFile(ctx, data); err != nil {
+		return fmt.Errorf("write view failed: %w", err)
 	}
 	if route != "" {
 		if err := writeControllerRouteFile(data); err != nil {
-			return fmt.Errorf("write route failed: %s", err)
+			return fmt.Errorf("write route failed: %w", err)
 		}
 	}
 
@@ -98,38 +96,34 @@ func controller(c *config.Config, q *registry.Queue) error {
 }
 
 func controller_noview(c *config.Config, q *registry.Queue) error {
-	name := q.NextTask()
-	if name == "" {
-		return fmt.Errorf("first arg should be the name of the controller")
-	}
-	q.RemoveNextTask()
+	ctx := q.Context()
+	name := q.Args().String("name")
 	if !strings.Contains(name, "Ctrl") {
 		name = name + "Ctrl"
 	}
-	module := q.NextTask()
-	if module == "" {
-		return fmt.Errorf("second arg should be the module of the controller")
+	module := q.Args().String("module")
+	route := q.Args().String("route")
+	appPath, err := c.GetDefault("paths.app", filepath.Join("app", "scripts", "app.js"))
+	if err != nil {
+		return err
 	}
-	q.RemoveNextTask()
-	route := q.NextTask()
-	q.RemoveNextTask()
 
 	data := &controllerData{
 		Name:     name,
 		Module:   module,
 		Route:    route,
 		Filename: filepath.Join(strings.Split(module, ".")...),
-		AppPath:  c.GetDefault("paths.app", filepath.Join("app", "scripts", "app.js")),
+		AppPath:  appPath,
 	}
-	if err := writeControllerFile(data); err != nil {
-		return fmt.Errorf("write controller failed: %s", err)
+	if err := writeControllerFile(ctx, data); err != nil {
+		return fmt.Errorf("write controller failed: %w", err)
 	}
-	if err := writeControllerTestFile(data); err != nil {
-		return fmt.Errorf("write controller test failed: %s", err)
+	if err := writeControllerTestFile(ctx, data); err != nil {
+		return fmt.Errorf("write controller test failed: %w", err)
 	}
 	if route != "" {
 		if err := writeControllerRouteFile(data); err != nil {
-			return fmt.Errorf("write route failed: %s", err)
+			return fmt.Errorf("write route failed: %w", err)
 		}
 	}
 
@@ -143,9 +137,9 @@ type fileData struct {
 	Exists bool
 }
 
-func writeFile(path string, tmpl string, data interface{}) error {
+func writeFile(ctx context.Context, path string, tmpl string, data interface{}) error {
 	if *config.Verbose {
-		log.Printf("write file %s\n", path)
+		utils.Logf(ctx, "write file %s\n", path)
 	}
 
 	exists := true
@@ -153,29 +147,30 @@ func writeFile(path string, tmpl string, data interface{}) error {
 		if os.IsNotExist(err) {
 			exists = false
 		} else {
-			return fmt.Errorf("stat failed: %s", err)
+			return fmt.Errorf("stat failed: %w", err)
 		}
 	}
 
-	dir := filepath.Dir(path)
-	if err := os.MkdirAll(dir, 0755); err != nil {
-		return fmt.Errorf("mkdir all failed (%s): %s", dir, err)
+	var f io.WriteCloser
+	var err error
+	if exists {
+		f, err = utils.AppendFile(path)
+	} else {
+		f, err = utils.CreateFile(path)
 	}
-
-	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0666)
 	if err != nil {
-		return fmt.Errorf("open file failed: %s", err)
+		return fmt.Errorf("open file failed: %w", err)
 	}
 	defer f.Close()
 
 	tmpl = filepath.Join(utils.PackagePath(selfPkg), tmpl)
 	t, err := template.ParseFiles(tmpl)
 	if err != nil {
-		return fmt.Errorf("parse template failed: %s", err)
+		return fmt.Errorf("parse template failed: %w", err)
 	}
 
 	if err := t.Execute(f, &fileData{data, exists}); err != nil {
-		return fmt.Errorf("execute template failed: %s", err)
+		return fmt.Errorf("execute template failed: %w", err)
 	}
 
 	return nil
@@ -187,14 +182,14 @@ type serviceData struct {
 	Name, Module, Filename string
 }
 
-func writeServiceFile(data *serviceData) error {
+func writeServiceFile(ctx context.Context, data *serviceData) error {
 	p := filepath.Join("app", "scripts", "services", data.Filename+".js")
-	return writeFile(p, "service.js", data)
+	return writeFile(ctx, p, "service.js", data)
 }
 
-func writeServiceTestFile(data *serviceData) error {
+func writeServiceTestFile(ctx context.Context, data *serviceData) error {
 	p := filepath.Join("test", "unit", "services", data.Filename+"Spec.js")
-	return writeFile(p, "serviceSpec.js", data)
+	return writeFile(ctx, p, "serviceSpec.js", data)
 }
 
 // ==================================================================
@@ -207,17 +202,17 @@ type controllerData struct {
 	ViewName string
 }
 
-func writeControllerFile(data *controllerData) error {
+func writeControllerFile(ctx context.Context, data *controllerData) error {
 	p := filepath.Join("app", "scripts", "controllers", data.Filename+".js")
-	return writeFile(p, "controller.js", data)
+	return writeFile(ctx, p, "controller.js", data)
 }
 
-func writeControllerTestFile(data *controllerData) error {
+func writeControllerTestFile(ctx context.Context, data *controllerData) error {
 	p := filepath.Join("test", "unit", "controllers", data.Filename+"Spec.js")
-	return writeFile(p, "controllerSpec.js", data)
+	return writeFile(ctx, p, "controllerSpec.js", data)
 }
 
-func writeControllerViewFile(data *controllerData) error {
+func writeControllerViewFile(ctx context.Context, data *controllerData) error {
 	name := data.Name[:len(data.Name)-4]
 	filename := ""
 	for i, c := range name {
@@ -232,13 +227,13 @@ func writeControllerViewFile(data *controllerData) error {
 	data.ViewName = filepath.Join("views", data.Filename, filename+".html")
 
 	p := filepath.Join("app", data.ViewName)
-	return writeFile(p, "view.html", data)
+	return writeFile(ctx, p, "view.html", data)
 }
 
 func writeControllerRouteFile(data *controllerData) error {
 	lines, err := utils.ReadLines(data.AppPath)
 	if err != nil {
-		return fmt.Errorf("read lines failed: %s", err)
+		return fmt.Errorf("read lines failed: %w", err)
 	}
 
 	newlines := []string{}
@@ -265,7 +260,7 @@ func writeControllerRouteFile(data *controllerData) error {
 	}
 
 	if err := utils.WriteFile(data.AppPath, strings.Join(newlines, "")); err != nil {
-		return fmt.Errorf("write file failed: %s", err)
+		return fmt.Errorf("write file failed: %w", err)
 	}
 
 	return nil
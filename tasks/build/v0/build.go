@@ -8,29 +8,26 @@ import (
 )
 
 func init() {
-	registry.NewUserTask("build", 0, build)
-	registry.NewUserTask("compile", 0, build)
+	desc := "build the app for production and deploy it"
+	registry.NewUserTask("build", 0, build).
+		Describe(desc).
+		RequiresFirst("update:check@0").
+		Requires("dist:copy@0").
+		Reads("[deploy.mode]")
+	registry.NewUserTask("compile", 0, build).
+		Describe(desc).
+		RequiresFirst("update:check@0").
+		Requires("dist:copy@0").
+		Reads("[deploy.mode]")
 }
 
+// The build steps themselves are prerequisites of dist:copy; here we only
+// append the deploy task configured for the project.
 func build(c *config.Config, q *registry.Queue) error {
-	q.AddTasks([]string{
-		"update:check@0",
-		"clean@0",
-		"dist:prepare@0",
-		"recess:build@0",
-		"sass:build@0",
-		"imagemin@0",
-		"minignore@0",
-		"ngmin@0",
-		"compilejs@0",
-		"concat@0",
-		"htmlmin@0",
-		"ngtemplates@0",
-		"cacherev@0",
-		"dist:copy@0",
-	})
-
-	deploy := c.GetDefault("deploy.mode", "")
+	deploy, err := c.GetDefault("deploy.mode", "")
+	if err != nil {
+		return err
+	}
 	if len(deploy) > 0 {
 		q.AddTask(fmt.Sprintf("deploy:%s", deploy))
 	}
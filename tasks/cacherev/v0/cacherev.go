@@ -1,10 +1,11 @@
 package v0
 
 import (
+	"context"
 	"crypto/sha1"
+	"errors"
 	"fmt"
 	"io/ioutil"
-	"log"
 	"os"
 	"path/filepath"
 	"strings"
@@ -15,7 +16,11 @@ import (
 )
 
 var (
-	changes     = map[string]string{}
+	changes = map[string]string{}
+
+	// Original content of the files whose references were changed
+	edited = map[string]string{}
+
 	allowedExts = map[string]bool{
 		".gif":  true,
 		".js":   true,
@@ -34,43 +39,61 @@ var (
 )
 
 func init() {
-	registry.NewTask("cacherev", 0, cacherev)
+	registry.NewTask("cacherev", 0, cacherev).
+		Describe("rename the files with a hash of their content").
+		Requires("ngtemplates@0", "imagemin@0").
+		Reads("<cacherev.dirs:list>", "<cacherev.exclude:list>", "[cacherev.rev:list]")
 }
 
 func cacherev(c *config.Config, q *registry.Queue) error {
-	dirs := c.GetListRequired("cacherev.dirs")
-	exclude := c.GetListRequired("cacherev.exclude")
+	q.OnCleanup(undoChanges)
+
+	dirs, err := c.GetListRequired("cacherev.dirs")
+	if err != nil {
+		return err
+	}
+	exclude, err := c.GetListRequired("cacherev.exclude")
+	if err != nil {
+		return err
+	}
 	for _, dir := range dirs {
 		dir = filepath.Join("temp", dir)
-		if err := filepath.Walk(dir, changeName(exclude)); err != nil {
-			return fmt.Errorf("change names walk failed (%s): %s", dir, err)
+		if err := filepath.Walk(dir, changeName(q.Context(), exclude)); err != nil {
+			return fmt.Errorf("change names walk failed (%s): %w", dir, err)
 		}
 	}
 
-	rev := c.GetListDefault("cacherev.rev")
+	rev, err := c.GetListDefault("cacherev.rev")
+	if err != nil {
+		return err
+	}
 	for _, dir := range rev {
 		dir = filepath.Join("temp", dir)
-		if err := filepath.Walk(dir, changeReferences); err != nil {
-			return fmt.Errorf("change references walk failed (%s): %s", dir, err)
+		if err := filepath.Walk(dir, changeReferences(q.Context())); err != nil {
+			return fmt.Errorf("change references walk failed (%s): %w", dir, err)
 		}
 	}
 
 	utils.SaveChanges(changes)
+	edited = map[string]string{}
 	return nil
 }
 
-func changeName(excludes []string) filepath.WalkFunc {
+func changeName(ctx context.Context, excludes []string) filepath.WalkFunc {
 	return func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			if os.IsNotExist(err) {
 				return nil
 			}
-			return fmt.Errorf("walk failed: %s", err)
+			return fmt.Errorf("walk failed: %w", err)
+		}
+		if err := ctx.Err(); err != nil {
+			return err
 		}
 
 		rel, err := filepath.Rel("temp", path)
 		if err != nil {
-			return fmt.Errorf("cannot rel: %s", err)
+			return fmt.Errorf("cannot rel: %w", err)
 		}
 
 		for _, exclude := range excludes {
@@ -87,71 +110,95 @@ func changeName(excludes []string) filepath.WalkFunc {
 
 		newname, err := calcNewName(path)
 		if err != nil {
-			return fmt.Errorf("calc name failed: %s", err)
+			return fmt.Errorf("calc name failed: %w", err)
 		}
 		newpath := filepath.Join(filepath.Dir(rel), newname)
 
+		abspath := filepath.Join("temp", newpath)
+		if err := utils.Rename(path, abspath); err != nil {
+			return fmt.Errorf("rename failed: %w", err)
+		}
 		changes[rel] = newpath
 		if *config.Verbose {
-			log.Printf("`%s` converted to `%s`\n", filepath.Base(path), newname)
+			utils.Logf(ctx, "`%s` converted to `%s`\n", filepath.Base(path), newname)
 		}
+		return nil
+	}
+}
 
-		abspath := filepath.Join("temp", newpath)
-		if err := os.Rename(path, abspath); err != nil {
-			return fmt.Errorf("rename failed: %s", err)
+// undoChanges restores the references & renames back the files of a
+// partial pass. It tries every file even if some of them fail.
+func undoChanges() error {
+	var errs []error
+	for path, content := range edited {
+		if err := utils.WriteFile(path, content); err != nil {
+			errs = append(errs, fmt.Errorf("restore references failed: %w", err))
+			continue
 		}
-		return nil
+		delete(edited, path)
+	}
+	for rel, newpath := range changes {
+		if err := utils.Rename(filepath.Join("temp", newpath), filepath.Join("temp", rel)); err != nil {
+			errs = append(errs, fmt.Errorf("rename failed: %w", err))
+			continue
+		}
+		delete(changes, rel)
 	}
+	return errors.Join(errs...)
 }
 
 func calcNewName(path string) (string, error) {
 	f, err := os.Open(path)
 	if err != nil {
-		return "", fmt.Errorf("open failed: %s", err)
+		return "", fmt.Errorf("open failed: %w", err)
 	}
 	defer f.Close()
 
 	content, err := ioutil.ReadAll(f)
 	if err != nil {
-		return "", fmt.Errorf("read failed: %s", err)
+		return "", fmt.Errorf("read failed: %w", err)
 	}
 
 	h := sha1.New()
 	if _, err := h.Write(content); err != nil {
-		return "", fmt.Errorf("write failed: %s", err)
+		return "", fmt.Errorf("write failed: %w", err)
 	}
 
 	enc := fmt.Sprintf("%x", h.Sum(nil))
 	return fmt.Sprintf("%s.%s", enc[:8], filepath.Base(path)), nil
 }
 
-func changeReferences(path string, info os.FileInfo, err error) error {
-	if err != nil {
-		return fmt.Errorf("walk failed: %s", err)
-	}
-	if info.IsDir() {
-		return nil
-	}
-
-	f, err := os.Open(path)
-	if err != nil {
-		return fmt.Errorf("open failed: %s", err)
-	}
-	defer f.Close()
+func changeReferences(ctx context.Context) filepath.WalkFunc {
+	return func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return fmt.Errorf("walk failed: %w", err)
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
 
-	content, err := ioutil.ReadAll(f)
-	if err != nil {
-		return fmt.Errorf("read failed: %s", err)
-	}
+		content, err := ioutil.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("read failed: %w", err)
+		}
 
-	s := string(content)
-	for old, change := range changes {
-		s = strings.Replace(s, old, change, -1)
-	}
+		s := string(content)
+		for old, change := range changes {
+			s = strings.Replace(s, old, change, -1)
+		}
+		if s == string(content) {
+			return nil
+		}
 
-	if err := utils.WriteFile(path, s); err != nil {
-		return fmt.Errorf("write failed: %s", err)
+		if _, ok := edited[path]; !ok {
+			edited[path] = string(content)
+		}
+		if err := utils.WriteFile(path, s); err != nil {
+			return fmt.Errorf("write failed: %w", err)
+		}
+		return nil
 	}
-
-	return nil
 }
@@ -5,14 +5,17 @@ import (
 
 	"github.com/ernestokarim/cb/config"
 	"github.com/ernestokarim/cb/registry"
+	"github.com/ernestokarim/cb/utils"
 )
 
 func init() {
-	registry.NewUserTask("cbtest", 0, cbtest)
+	registry.NewUserTask("cbtest", 0, cbtest).
+		Describe("print the loaded config")
 }
 
 func cbtest(c *config.Config, q *registry.Queue) error {
-	fmt.Println("Hello World!")
-	c.Render()
+	w := utils.Output(q.Context())
+	fmt.Fprintln(w, "Hello World!")
+	c.Render(w)
 	return nil
 }
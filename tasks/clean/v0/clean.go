@@ -2,26 +2,27 @@ package v0
 
 import (
 	"fmt"
-	"log"
-	"os"
 
 	"github.com/ernestokarim/cb/config"
 	"github.com/ernestokarim/cb/registry"
+	"github.com/ernestokarim/cb/utils"
 )
 
 func init() {
-	registry.NewUserTask("clear", 0, clean)
-	registry.NewUserTask("clean", 0, clean)
+	registry.NewUserTask("clear", 0, clean).
+		Describe("remove the temp & dist folders")
+	registry.NewUserTask("clean", 0, clean).
+		Describe("remove the temp & dist folders")
 }
 
 func clean(c *config.Config, q *registry.Queue) error {
 	folders := []string{"temp", "dist"}
 	for _, folder := range folders {
-		if err := os.RemoveAll(folder); err != nil {
-			return fmt.Errorf("remove node failed: %s", err)
+		if err := utils.RemoveAll(folder); err != nil {
+			return fmt.Errorf("remove node failed: %w", err)
 		}
 		if *config.Verbose {
-			log.Printf("remove %s\n", folder)
+			utils.Logf(q.Context(), "remove %s\n", folder)
 		}
 	}
 	return nil
@@ -1,9 +1,8 @@
 package v0
 
 import (
+	"context"
 	"fmt"
-	"log"
-	"os"
 	"path/filepath"
 	"regexp"
 	"strings"
@@ -19,14 +18,24 @@ var (
 )
 
 func init() {
-	registry.NewTask("compilejs", 0, compilejs)
+	registry.NewTask("compilejs", 0, compilejs).
+		Describe("compile the scripts with the closure compiler").
+		Requires("minignore@0", "ngmin@0").
+		Reads("<paths.base>").
+		Inputs("temp/${paths.base:base}", "temp/**/*.js").
+		Outputs("temp/${paths.base:base}").
+		Tool("uglifyjs", "--version")
 }
 
 func compilejs(c *config.Config, q *registry.Queue) error {
-	base := filepath.Join("temp", filepath.Base(c.GetRequired("paths.base")))
+	base, err := c.GetRequired("paths.base")
+	if err != nil {
+		return err
+	}
+	base = filepath.Join("temp", filepath.Base(base))
 	lines, err := utils.ReadLines(base)
 	if err != nil {
-		return fmt.Errorf("read base html failed: %s", err)
+		return fmt.Errorf("read base html failed: %w", err)
 	}
 	for i := 0; i < len(lines); i++ {
 		line := lines[i]
@@ -57,25 +66,27 @@ func compilejs(c *config.Config, q *registry.Queue) error {
 				return fmt.Errorf("no files found to compile %s", match[1])
 			}
 
-			if err := compileJs(match[1], files); err != nil {
-				return fmt.Errorf("compile js failed: %s", err)
+			if err := compileJs(q.Context(), match[1], files); err != nil {
+				return fmt.Errorf("compile js failed: %w", err)
 			}
+			q.Produced(filepath.Join("temp", match[1]))
 			line = fmt.Sprintf("<script src=\"%s\"></script>\n", match[1])
 		}
 		lines[i] = line
 	}
 
 	if err := utils.WriteFile(base, strings.Join(lines, "")); err != nil {
-		return fmt.Errorf("write file failed: %s", err)
+		return fmt.Errorf("write file failed: %w", err)
 	}
+	q.Produced(base)
 	return nil
 }
 
-func compileJs(dest string, srcs []string) error {
+func compileJs(ctx context.Context, dest string, srcs []string) error {
 	destPath := filepath.Join("temp", dest)
 	dir := filepath.Dir(destPath)
-	if err := os.MkdirAll(dir, 0755); err != nil {
-		return fmt.Errorf("prepare dest dir failed (%s): %s", dir, err)
+	if err := utils.MkdirAll(dir); err != nil {
+		return fmt.Errorf("prepare dest dir failed (%s): %w", dir, err)
 	}
 
 	args := []string{}
@@ -84,13 +95,13 @@ func compileJs(dest string, srcs []string) error {
 	}
 	args = append(args, "-o", destPath, "-c", "-m")
 
-	output, err := utils.Exec("uglifyjs", args)
+	output, err := utils.Exec(ctx, "uglifyjs", args)
 	if err != nil {
-		fmt.Println(output)
-		return fmt.Errorf("compiler error: %s", err)
+		fmt.Fprintln(utils.Output(ctx), output)
+		return fmt.Errorf("compiler error: %w", err)
 	}
 	if *config.Verbose {
-		log.Printf("compile file `%s` with %d sources\n", dest, len(srcs))
+		utils.Logf(ctx, "compile file `%s` with %d sources\n", dest, len(srcs))
 	}
 	return nil
 }
@@ -1,9 +1,9 @@
 package v0
 
 import (
+	"context"
 	"fmt"
 	"io"
-	"log"
 	"os"
 	"path/filepath"
 	"regexp"
@@ -22,14 +22,21 @@ var (
 )
 
 func init() {
-	registry.NewTask("concat", 0, concat)
+	registry.NewTask("concat", 0, concat).
+		Describe("join the scripts & styles referenced by the base file").
+		Requires("compilejs@0", "recess:build@0", "sass:build@0").
+		Reads("<paths.base>")
 }
 
 func concat(c *config.Config, q *registry.Queue) error {
-	base := filepath.Join("temp", filepath.Base(c.GetRequired("paths.base")))
+	base, err := c.GetRequired("paths.base")
+	if err != nil {
+		return err
+	}
+	base = filepath.Join("temp", filepath.Base(base))
 	lines, err := utils.ReadLines(base)
 	if err != nil {
-		return fmt.Errorf("read base html failed: %s", err)
+		return fmt.Errorf("read base html failed: %w", err)
 	}
 	for i := 0; i < len(lines); i++ {
 		line := lines[i]
@@ -60,8 +67,8 @@ func concat(c *config.Config, q *registry.Queue) error {
 				return fmt.Errorf("no files found to compile %s", match[1])
 			}
 
-			if err := concatFiles(match[2], files); err != nil {
-				return fmt.Errorf("concat files failed: %s", err)
+			if err := concatFiles(q.Context(), match[2], files); err != nil {
+				return fmt.Errorf("concat files failed: %w", err)
 			}
 			line = fmt.Sprintf("<link rel=\"stylesheet\" href=\"%s\">\n", match[2])
 		} else if strings.Contains(line, "<!-- concat:js") {
@@ -98,8 +105,8 @@ func concat(c *config.Config, q *registry.Queue) error {
 				return fmt.Errorf("no files found to compile %s", match[1])
 			}
 
-			if err := concatFiles(match[2], files); err != nil {
-				return fmt.Errorf("concat files failed: %s", err)
+			if err := concatFiles(q.Context(), match[2], files); err != nil {
+				return fmt.Errorf("concat files failed: %w", err)
 			}
 			if pos == -1 {
 				line = fmt.Sprintf("<script src=\"%s\"></script>\n", match[2])
@@ -112,32 +119,32 @@ func concat(c *config.Config, q *registry.Queue) error {
 	}
 
 	if err := utils.WriteFile(base, strings.Join(lines, "")); err != nil {
-		return fmt.Errorf("write file failed: %s", err)
+		return fmt.Errorf("write file failed: %w", err)
 	}
 	return nil
 }
 
-func concatFiles(dest string, srcs []string) error {
-	fdest, err := os.Create(filepath.Join("temp", dest))
+func concatFiles(ctx context.Context, dest string, srcs []string) error {
+	fdest, err := utils.CreateFile(filepath.Join("temp", dest))
 	if err != nil {
-		return fmt.Errorf("create dest file failed: %s", err)
+		return fmt.Errorf("create dest file failed: %w", err)
 	}
 	defer fdest.Close()
 
 	for _, src := range srcs {
 		fsrc, err := os.Open(filepath.Join("temp", src))
 		if err != nil {
-			return fmt.Errorf("open source file failed: %s", err)
+			return fmt.Errorf("open source file failed: %w", err)
 		}
 		defer fsrc.Close()
 
 		if _, err := io.Copy(fdest, fsrc); err != nil {
-			return fmt.Errorf("error copying file: %s", err)
+			return fmt.Errorf("error copying file: %w", err)
 		}
 	}
 
 	if *config.Verbose {
-		log.Printf("concat file `%s` with %d sources\n", dest, len(srcs))
+		utils.Logf(ctx, "concat file `%s` with %d sources\n", dest, len(srcs))
 	}
 	return nil
 }
@@ -0,0 +1,45 @@
+package v0
+
+import (
+	"fmt"
+
+	"github.com/ernestokarim/cb/colors"
+	"github.com/ernestokarim/cb/config"
+	"github.com/ernestokarim/cb/registry"
+	"github.com/ernestokarim/cb/utils"
+)
+
+// Tasks whose required keys are checked if none is passed
+var defaultCheckTasks = []string{"build", "server"}
+
+func init() {
+	registry.NewUserTask("config:check", 0, check).
+		Describe("validate the config file against the keys the tasks use").
+		Args("[tasks:task...]")
+}
+
+func check(c *config.Config, q *registry.Queue) error {
+	tasks := q.Args().List("tasks")
+	if len(tasks) == 0 {
+		tasks = defaultCheckTasks
+	}
+
+	w := utils.Output(q.Context())
+	errors := 0
+	problems := registry.CheckConfig(c, tasks)
+	for _, p := range problems {
+		color := colors.Yellow
+		if !p.Warning {
+			color = colors.Red
+			errors++
+		}
+		fmt.Fprintf(w, "%s%s%s\n", color, p, colors.Reset)
+	}
+
+	if errors > 0 {
+		return fmt.Errorf("%d errors found in the config file", errors)
+	}
+	fmt.Fprintf(w, "%sconfig file is valid (%d warnings)%s\n", colors.Green,
+		len(problems), colors.Reset)
+	return nil
+}
@@ -0,0 +1,62 @@
+package v0
+
+import (
+	"fmt"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/ernestokarim/cb/colors"
+	"github.com/ernestokarim/cb/config"
+	"github.com/ernestokarim/cb/registry"
+	"github.com/ernestokarim/cb/utils"
+)
+
+func init() {
+	registry.NewUserTask("config:explain", 0, explain).
+		Describe("list the config keys each task reads, with their defaults & current values").
+		Args("[tasks:task...]")
+}
+
+func explain(c *config.Config, q *registry.Queue) error {
+	configs := registry.TaskConfigs()
+
+	names := q.Args().List("tasks")
+	if len(names) == 0 {
+		for name := range configs {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+	}
+
+	w := tabwriter.NewWriter(utils.Output(q.Context()), 0, 8, 2, ' ', 0)
+	for _, name := range names {
+		if !registry.IsTask(name) {
+			return fmt.Errorf("task not found: %s", name)
+		}
+		keys := configs[name]
+		if len(keys) == 0 {
+			continue
+		}
+
+		fmt.Fprintf(w, "\n * %s\n", name)
+		fmt.Fprintf(w, "    KEY\tKIND\tDEFAULT\tVALUE\n")
+		for _, key := range keys {
+			def := key.Default
+			if key.Required {
+				def = "(required)"
+			} else if def == "" {
+				def = "-"
+			}
+
+			value, ok := c.Summary(key.Path)
+			if !ok && key.Required {
+				value = fmt.Sprintf("%s(missing)%s", colors.Red, colors.Reset)
+			} else if !ok {
+				value = "-"
+			}
+			fmt.Fprintf(w, "    %s\t%s\t%s\t%s\n", key.Path, key.Kind, def, value)
+		}
+	}
+	fmt.Fprintln(w)
+	return w.Flush()
+}
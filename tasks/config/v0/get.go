@@ -0,0 +1,24 @@
+package v0
+
+import (
+	"fmt"
+
+	"github.com/ernestokarim/cb/config"
+	"github.com/ernestokarim/cb/registry"
+	"github.com/ernestokarim/cb/utils"
+)
+
+func init() {
+	registry.NewUserTask("config:get", 0, get).
+		Describe("print a value of the effective config (paths like a.b[0].c)").
+		Args("<path>")
+}
+
+func get(c *config.Config, q *registry.Queue) error {
+	value, err := c.Lookup("%s", q.Args().String("path"))
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(utils.Output(q.Context()), value)
+	return nil
+}
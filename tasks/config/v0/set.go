@@ -0,0 +1,35 @@
+package v0
+
+import (
+	"fmt"
+
+	"github.com/ernestokarim/cb/colors"
+	"github.com/ernestokarim/cb/config"
+	"github.com/ernestokarim/cb/registry"
+	"github.com/ernestokarim/cb/utils"
+)
+
+func init() {
+	registry.NewUserTask("config:set", 0, set).
+		Describe("change a value of the config file, keeping its comments").
+		Args("<path>", "<value>")
+}
+
+func set(c *config.Config, q *registry.Queue) error {
+	path := q.Args().String("path")
+	value := q.Args().String("value")
+
+	content, err := c.EditValue(path, value)
+	if err != nil {
+		return err
+	}
+	if err := utils.WriteFile(c.Filename(), content); err != nil {
+		return fmt.Errorf("write config failed: %w", err)
+	}
+
+	if len(c.Includes()) > 0 || c.Env() != "" {
+		utils.Warningf(q.Context(), "includes & environments can still override %s", path)
+	}
+	fmt.Fprintf(utils.Output(q.Context()), "%s%s = %s%s\n", colors.Green, path, value, colors.Reset)
+	return nil
+}
@@ -0,0 +1,28 @@
+package v0
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ernestokarim/cb/config"
+	"github.com/ernestokarim/cb/registry"
+	"github.com/ernestokarim/cb/utils"
+)
+
+func init() {
+	registry.NewUserTask("config:show", 0, show).
+		Describe("print the effective config, with the includes & -env overlays merged")
+}
+
+func show(c *config.Config, q *registry.Queue) error {
+	w := utils.Output(q.Context())
+	if len(c.Includes()) > 0 {
+		fmt.Fprintf(w, "# included: %s\n", strings.Join(c.Includes(), ", "))
+	}
+	if c.Env() != "" {
+		fmt.Fprintf(w, "# environment: %s\n", c.Env())
+		fmt.Fprintf(w, "# merged: %s\n", strings.Join(c.Overlays(), ", "))
+	}
+	c.Render(w)
+	return nil
+}
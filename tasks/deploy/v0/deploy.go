@@ -1,8 +1,8 @@
 package v0
 
 import (
+	"context"
 	"fmt"
-	"log"
 	"os"
 	"path/filepath"
 	"strings"
@@ -15,46 +15,61 @@ import (
 const selfPkg = "github.com/ernestokarim/cb/tasks/deploy/v0/scripts"
 
 func init() {
-	registry.NewUserTask("deploy:laravel", 0, deploy)
+	registry.NewUserTask("deploy:laravel", 0, deploy).
+		Describe("copy the build to the deploy folder of a laravel app").
+		Reads("<paths.base>", "[deploy.exclude:list]", "[deploy.include:list]",
+			"[deploy.moves:list]")
 }
 
 func deploy(c *config.Config, q *registry.Queue) error {
+	ctx := q.Context()
 	parts := strings.Split(q.CurTask, ":")
 	base := utils.PackagePath(filepath.Join(selfPkg, parts[1]+".sh"))
 
+	index, err := c.GetRequired("paths.base")
+	if err != nil {
+		return err
+	}
 	args := []string{
-		filepath.Base(c.GetRequired("paths.base")),
+		filepath.Base(index),
 	}
-	if err := utils.ExecCopyOutput(base, args); err != nil {
-		return fmt.Errorf("deploy failed: %s", err)
+	if err := utils.ExecCopyOutput(ctx, base, args); err != nil {
+		return fmt.Errorf("deploy failed: %w", err)
 	}
 
-	if err := organizeResult(c); err != nil {
-		return fmt.Errorf("cannot organize result: %s", err)
+	if err := organizeResult(ctx, c); err != nil {
+		return fmt.Errorf("cannot organize result: %w", err)
 	}
 
 	return nil
 }
 
-func organizeResult(c *config.Config) error {
-	excludes := c.GetListDefault("deploy.exclude")
-	includes := c.GetListDefault("deploy.include")
-	moves := c.GetListDefault("deploy.moves")
+func organizeResult(ctx context.Context, c *config.Config) error {
+	excludes, err := c.GetListDefault("deploy.exclude")
+	if err != nil {
+		return err
+	}
+	includes, err := c.GetListDefault("deploy.include")
+	if err != nil {
+		return err
+	}
+	moves, err := c.GetListDefault("deploy.moves")
+	if err != nil {
+		return err
+	}
 
 	// Extract list of paths to remove
 	removePaths := map[string]bool{}
 	walkFn := func(path string, info os.FileInfo) error {
 		removePaths[path] = true
 		if *config.Verbose {
-			log.Printf("flag to remove `%s`...\n", path)
+			utils.Logf(ctx, "flag to remove `%s`...\n", path)
 		}
 		return nil
 	}
-	for _, exclude := range excludes {
-		exclude = filepath.Join("..", "deploy", exclude)
-		if err := utils.NewWalker(exclude).Walk(walkFn); err != nil {
-			fmt.Errorf("deploy exclude walker failed: %s", err)
-		}
+	excludes = utils.JoinPatterns(filepath.Join("..", "deploy"), excludes)
+	if err := utils.NewWalker(excludes...).Walk(walkFn); err != nil {
+		return fmt.Errorf("deploy exclude walker failed: %w", err)
 	}
 
 	// Cancel removing of files that are included again
@@ -65,25 +80,23 @@ func organizeResult(c *config.Config) error {
 			cur = filepath.Dir(cur)
 		}
 		if *config.Verbose {
-			log.Printf("include `%s`...\n", path)
+			utils.Logf(ctx, "include `%s`...\n", path)
 		}
 		return nil
 	}
-	for _, include := range includes {
-		include = filepath.Join("..", "deploy", include)
-		if err := utils.NewWalker(include).Walk(walkFn); err != nil {
-			fmt.Errorf("deploy include walker failed: %s", err)
-		}
+	includes = utils.JoinPatterns(filepath.Join("..", "deploy"), includes)
+	if err := utils.NewWalker(includes...).Walk(walkFn); err != nil {
+		return fmt.Errorf("deploy include walker failed: %w", err)
 	}
 
 	// Remove flagged files & folders
 	for path, remove := range removePaths {
 		if remove {
 			if *config.Verbose {
-				log.Printf("removing `%s`...\n", path)
+				utils.Logf(ctx, "removing `%s`...\n", path)
 			}
-			if err := os.RemoveAll(path); err != nil {
-				return fmt.Errorf("cannot remove deploy entry: %s", err)
+			if err := utils.RemoveAll(path); err != nil {
+				return fmt.Errorf("cannot remove deploy entry: %w", err)
 			}
 		}
 	}
@@ -94,14 +107,14 @@ func organizeResult(c *config.Config) error {
 		origin := filepath.Join("..", "deploy", strings.TrimSpace(parts[0]))
 		dest := filepath.Join("..", "deploy", strings.TrimSpace(parts[1]))
 
-		if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
-			return fmt.Errorf("cannot create dest tree structure: %s", err)
+		if err := utils.MkdirAll(filepath.Dir(dest)); err != nil {
+			return fmt.Errorf("cannot create dest tree structure: %w", err)
 		}
 
-		output, err := utils.Exec("cp", []string{"-r", origin, dest})
+		output, err := utils.Exec(ctx, "cp", []string{"-r", origin, dest})
 		if err != nil {
-			fmt.Println(output)
-			return fmt.Errorf("copy error: %s", err)
+			fmt.Fprintln(utils.Output(ctx), output)
+			return fmt.Errorf("copy error: %w", err)
 		}
 	}
 
@@ -2,7 +2,6 @@ package v0
 
 import (
 	"fmt"
-	"log"
 	"os"
 	"path/filepath"
 	"strings"
@@ -13,12 +12,22 @@ import (
 )
 
 func init() {
-	registry.NewTask("dist:prepare", 0, prepareDist)
-	registry.NewTask("dist:copy", 0, copyDist)
+	registry.NewTask("dist:prepare", 0, prepareDist).
+		Describe("copy the sources to the temp folder").
+		Requires("clean@0").
+		Reads("<dist.prepare:list>")
+	registry.NewTask("dist:copy", 0, copyDist).
+		Describe("copy the compiled files to the dist folder").
+		Requires("cacherev@0").
+		Reads("<dist.final:list>")
 }
 
 func prepareDist(c *config.Config, q *registry.Queue) error {
-	dirs := c.GetListRequired("dist.prepare")
+	ctx := q.Context()
+	dirs, err := c.GetListRequired("dist.prepare")
+	if err != nil {
+		return err
+	}
 	for _, from := range dirs {
 		to := "temp"
 		if strings.Contains(from, "->") {
@@ -31,24 +40,28 @@ func prepareDist(c *config.Config, q *registry.Queue) error {
 			if os.IsNotExist(err) {
 				continue
 			}
-			return fmt.Errorf("stat failed: %s", err)
+			return fmt.Errorf("stat failed: %w", err)
 		}
 
-		if err := os.MkdirAll(filepath.Dir(to), 0755); err != nil {
-			return fmt.Errorf("prepare dir failed (%s): %s", to, err)
+		if err := utils.MkdirAll(filepath.Dir(to)); err != nil {
+			return fmt.Errorf("prepare dir failed (%s): %w", to, err)
 		}
 
-		output, err := utils.Exec("cp", []string{"-r", from, to})
+		output, err := utils.Exec(ctx, "cp", []string{"-r", from, to})
 		if err != nil {
-			fmt.Println(output)
-			return fmt.Errorf("copy error: %s", err)
+			fmt.Fprintln(utils.Output(ctx), output)
+			return fmt.Errorf("copy error: %w", err)
 		}
 	}
 	return nil
 }
 
 func copyDist(c *config.Config, q *registry.Queue) error {
-	dirs := c.GetListRequired("dist.final")
+	ctx := q.Context()
+	dirs, err := c.GetListRequired("dist.final")
+	if err != nil {
+		return err
+	}
 
 	changes := utils.LoadChanges()
 	for i, dir := range dirs {
@@ -69,18 +82,18 @@ func copyDist(c *config.Config, q *registry.Queue) error {
 		origin := filepath.Join("temp", from)
 		dest := filepath.Join("dist", to)
 
-		if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
-			return fmt.Errorf("prepare dir failed (%s): %s", dir, err)
+		if err := utils.MkdirAll(filepath.Dir(dest)); err != nil {
+			return fmt.Errorf("prepare dir failed (%s): %w", dir, err)
 		}
 
 		if *config.Verbose {
-			log.Printf("copy `%s`\n", origin)
+			utils.Logf(ctx, "copy `%s`\n", origin)
 		}
 
-		output, err := utils.Exec("cp", []string{"-r", origin, dest})
+		output, err := utils.Exec(ctx, "cp", []string{"-r", origin, dest})
 		if err != nil {
-			fmt.Println(output)
-			return fmt.Errorf("copy error: %s", err)
+			fmt.Fprintln(utils.Output(ctx), output)
+			return fmt.Errorf("copy error: %w", err)
 		}
 	}
 
@@ -7,22 +7,54 @@ import (
 	"github.com/ernestokarim/cb/tasks/form/v0/utils"
 )
 
+type definition struct {
+	Name           string `config:"name,required"`
+	Type           string `config:"type,required"`
+	Label          string
+	Help           string
+	Class          string
+	Size           string
+	LabelSize      string
+	Attrs          []*attr
+	ContainerAttrs []*attr
+	PlaceHolder    string `config:"placeholder"`
+	Prefix         string
+	Rows           int
+	DateFormat     string
+	IsOpen         string
+	Options        string
+	Content        string
+}
+
+type attr struct {
+	Name  string `config:"name,required"`
+	Value string
+}
+
 // Parse form fields
 func Parse(data *config.Config, idx int) (Field, error) {
+	def := &definition{
+		Rows:       3,
+		DateFormat: "dd/MM/yyyy",
+	}
+	if err := data.Decode(def, "fields[%d]", idx); err != nil {
+		return nil, err
+	}
+
 	base := &BaseField{
-		ID:             data.GetRequired("fields[%d].name", idx),
-		Name:           data.GetRequired("fields[%d].name", idx),
-		Label:          data.GetDefault("fields[%d].label", "", idx),
-		Help:           data.GetDefault("fields[%d].help", "", idx),
-		Class:          utils.SplitStrList(data.GetDefault("fields[%d].class", "", idx)),
-		Size:           utils.SplitStrList(data.GetDefault("fields[%d].size", "", idx)),
-		LabelSize:      utils.SplitStrList(data.GetDefault("fields[%d].labelSize", "", idx)),
-		Attrs:          parseAttrs(data, "attrs", idx),
-		ContainerAttrs: parseAttrs(data, "containerAttrs", idx),
+		ID:             def.Name,
+		Name:           def.Name,
+		Label:          def.Label,
+		Help:           def.Help,
+		Class:          utils.SplitStrList(def.Class),
+		Size:           utils.SplitStrList(def.Size),
+		LabelSize:      utils.SplitStrList(def.LabelSize),
+		Attrs:          parseAttrs(def.Attrs),
+		ContainerAttrs: parseAttrs(def.ContainerAttrs),
 	}
 
 	var field Field
-	fieldType := data.GetRequired("fields[%d].type", idx)
+	fieldType := def.Type
 	switch fieldType {
 	case "email":
 		fallthrough
@@ -37,16 +69,16 @@ func Parse(data *config.Config, idx int) (Field, error) {
 	case "text":
 		field = &inputField{
 			BaseField:   base,
-      PlaceHolder: data.GetDefault("fields[%d].placeholder", "", idx),
-			Prefix: data.GetDefault("fields[%d].prefix", "", idx),
+			PlaceHolder: def.PlaceHolder,
+			Prefix:      def.Prefix,
 			Type:        fieldType,
 		}
 
 	case "textarea":
 		field = &textAreaField{
 			BaseField:   base,
-			PlaceHolder: data.GetDefault("fields[%d].placeholder", "", idx),
-			Rows:        data.GetInt("fields[%d].rows", 3, idx),
+			PlaceHolder: def.PlaceHolder,
+			Rows:        def.Rows,
 		}
 
 	case "submit":
@@ -57,22 +89,22 @@ func Parse(data *config.Config, idx int) (Field, error) {
 	case "datepicker":
 		field = &datepickerField{
 			BaseField:   base,
-			PlaceHolder: data.GetDefault("fields[%d].placeholder", "", idx),
-			DateFormat:  data.GetDefault("fields[%d].dateFormat", "dd/MM/yyyy", idx),
-			IsOpen:      data.GetDefault("fields[%d].isOpen", "", idx),
-			Options:     data.GetDefault("fields[%d].options", "", idx),
+			PlaceHolder: def.PlaceHolder,
+			DateFormat:  def.DateFormat,
+			IsOpen:      def.IsOpen,
+			Options:     def.Options,
 		}
 
 	case "static":
 		field = &staticField{
 			BaseField: base,
-			Content:   data.GetDefault("fields[%d].content", "", idx),
+			Content:   def.Content,
 		}
 
 	case "custom":
 		field = &customField{
 			BaseField: base,
-			Content:   data.GetDefault("fields[%d].content", "", idx),
+			Content:   def.Content,
 		}
 		/*
 		   case "select":
@@ -101,16 +133,11 @@ func Parse(data *config.Config, idx int) (Field, error) {
 	return field, nil
 }
 
-func parseAttrs(data *config.Config, object string, idx int) map[string]string {
+func parseAttrs(attrs []*attr) map[string]string {
 	m := map[string]string{}
-
-	size := data.CountDefault("fields[%d].%s", idx, object)
-	for i := 0; i < size; i++ {
-		name := data.GetRequired("fields[%d].%s[%d].name", idx, object, i)
-		value := data.GetDefault("fields[%d].%s[%d].value", "", idx, object, i)
-		m[name] = value
+	for _, a := range attrs {
+		m[a.Name] = a.Value
 	}
-
 	return m
 }
 
@@ -15,8 +15,13 @@ import (
 )
 
 func init() {
-	registry.NewUserTask("form", 0, form_default)
-	registry.NewUserTask("form:*", 0, form)
+	registry.NewUserTask("form", 0, form_default).
+		Describe("copy to the clipboard the bootstrap3 form of a definition file").
+		Args("<filename>")
+	registry.NewUserTask("form:*", 0, form).
+		Describe("copy to the clipboard the form of a definition file, " +
+			"using the template mode after the colon").
+		Args("<filename>")
 }
 
 func form_default(c *config.Config, q *registry.Queue) error {
@@ -34,25 +39,19 @@ func doForm(c *config.Config, q *registry.Queue, mode string) error {
 	}
 	templates.SetMode(mode)
 
-	filename := q.NextTask()
-	if filename == "" {
-		return fmt.Errorf("validator filename not passed as an argument")
-	}
-	q.RemoveNextTask()
-
-	form, err := parseForm(filename)
+	form, err := parseForm(q.Args().String("filename"))
 	if err != nil {
-		return fmt.Errorf("parse form failed: %s", err)
+		return fmt.Errorf("parse form failed: %w", err)
 	}
 
 	result := strings.Replace(form.Build(), "'", `'"'"'`, -1)
 	args := []string{
 		"-c", fmt.Sprintf(`echo -n '%s' | xsel -bi`, result),
 	}
-	output, err := utils.Exec("bash", args)
+	output, err := utils.Exec(q.Context(), "bash", args)
 	if err != nil {
-		fmt.Println(output)
-		return fmt.Errorf("bash error: %s", err)
+		fmt.Fprintln(utils.Output(q.Context()), output)
+		return fmt.Errorf("bash error: %w", err)
 	}
 
 	return nil
@@ -61,35 +60,54 @@ func doForm(c *config.Config, q *registry.Queue, mode string) error {
 func parseForm(filename string) (*formInfo, error) {
 	f, err := yaml.ReadFile(filepath.Join("..", filename))
 	if err != nil {
-		return nil, fmt.Errorf("read form file failed: %s", err)
+		return nil, fmt.Errorf("read form file failed: %w", err)
 	}
 	data := config.NewConfig(f)
 
 	form := &formInfo{
 		Filename:   filename,
-		Name:       data.GetDefault("formname", "f"),
-		Submit:     data.GetDefault("submitfunc", "submit"),
-		TrySubmit:  data.GetDefault("trySubmitfunc", "trySubmit"),
-		ObjName:    data.GetDefault("objname", "data"),
 		Validators: make(map[string][]*validators.Validator),
 	}
+	settings := []struct {
+		dest     *string
+		key, def string
+	}{
+		{&form.Name, "formname", "f"},
+		{&form.Submit, "submitfunc", "submit"},
+		{&form.TrySubmit, "trySubmitfunc", "trySubmit"},
+		{&form.ObjName, "objname", "data"},
+	}
+	for _, setting := range settings {
+		value, err := data.GetDefault(setting.key, setting.def)
+		if err != nil {
+			return nil, fmt.Errorf("read form failed: %w", err)
+		}
+		*setting.dest = value
+	}
 
-	nfields := data.CountDefault("fields")
+	nfields, err := data.CountDefault("fields")
+	if err != nil {
+		return nil, fmt.Errorf("read form failed: %w", err)
+	}
 	for i := 0; i < nfields; i++ {
-		name := data.GetRequired("fields[%d].name", i)
+		name, err := data.GetRequired("fields[%d].name", i)
+		if err != nil {
+			return nil, fmt.Errorf("read form failed: %w", err)
+		}
 
 		field, err := fields.Parse(data, i)
 		if err != nil {
-			return nil, fmt.Errorf("parse field failed for `%s`: %s", name, err)
+			return nil, fmt.Errorf("parse field failed for `%s`: %w", name, err)
 		}
 		if field != nil {
 			form.Fields = append(form.Fields, field)
 		}
 
-		validators := validators.Parse(data, i)
-		if validators != nil {
-			form.Validators[name] = validators
+		validators, err := validators.Parse(data, i)
+		if err != nil {
+			return nil, fmt.Errorf("parse validators failed for `%s`: %w", name, err)
 		}
+		form.Validators[name] = validators
 	}
 
 	return form, nil
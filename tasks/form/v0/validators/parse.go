@@ -1,23 +1,37 @@
 package validators
 
 import (
+	"fmt"
+
 	"github.com/ernestokarim/cb/config"
 )
 
-func Parse(data *config.Config, idx int) []*Validator {
-	validators := []*Validator{}
+type definition struct {
+	Name  string `config:"name,required"`
+	Value string
+	Msg   string
+}
+
+func Parse(data *config.Config, idx int) ([]*Validator, error) {
+	defs := []*definition{}
+	size, err := data.CountDefault("fields[%d].validators", idx)
+	if err != nil {
+		return nil, err
+	}
+	if size > 0 {
+		if err := data.Decode(&defs, "fields[%d].validators", idx); err != nil {
+			return nil, err
+		}
+	}
 
-	nvalidators := data.CountDefault("fields[%d].validators", idx)
-	for i := 0; i < nvalidators; i++ {
-		name := data.GetRequired("fields[%d].validators[%d].name", idx, i)
-		value := data.GetDefault("fields[%d].validators[%d].value", "", idx, i)
-		msg := data.GetDefault("fields[%d].validators[%d].msg", "", idx, i)
-		validator := createValidator(name, value, msg)
+	validators := []*Validator{}
+	for _, def := range defs {
+		validator := createValidator(def.Name, def.Value, def.Msg)
 		if validator == nil {
-			panic("bad validator name: " + name)
+			return nil, fmt.Errorf("bad validator name: %s", def.Name)
 		}
 		validators = append(validators, validator)
 	}
 
-	return validators
+	return validators, nil
 }
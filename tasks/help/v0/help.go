@@ -0,0 +1,23 @@
+package v0
+
+import (
+	"github.com/ernestokarim/cb/config"
+	"github.com/ernestokarim/cb/registry"
+	"github.com/ernestokarim/cb/utils"
+)
+
+func init() {
+	registry.NewUserTask("help", 0, help).
+		Describe("print the usage of a task, or the list of tasks").
+		Args("[task]")
+}
+
+func help(c *config.Config, q *registry.Queue) error {
+	w := utils.Output(q.Context())
+	task := q.Args().String("task")
+	if task == "" {
+		registry.PrintTasks(w)
+		return nil
+	}
+	return registry.PrintHelp(w, task)
+}
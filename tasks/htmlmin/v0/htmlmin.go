@@ -1,6 +1,7 @@
 package v0
 
 import (
+	"context"
 	"fmt"
 	"path/filepath"
 
@@ -12,23 +13,38 @@ import (
 const selfPkg = "github.com/ernestokarim/cb/vendor"
 
 func init() {
-	registry.NewTask("htmlmin", 0, htmlmin)
+	registry.NewTask("htmlmin", 0, htmlmin).
+		Describe("compress the html files").
+		Requires("concat@0").
+		Reads("[htmlmin:list]", "<htmlmin[].source>", "<htmlmin[].dest>").
+		Inputs("${htmlmin[].source}").
+		Outputs("${htmlmin[].dest}").
+		Tool("java", "-version")
 }
 
 func htmlmin(c *config.Config, q *registry.Queue) error {
-	size := c.CountDefault("htmlmin")
+	size, err := c.CountDefault("htmlmin")
+	if err != nil {
+		return err
+	}
 	for i := 0; i < size; i++ {
-		source := c.GetRequired("htmlmin[%d].source", i)
-		dest := c.GetRequired("htmlmin[%d].dest", i)
-		if err := htmlcompressor(source, dest); err != nil {
-			return fmt.Errorf("html compress failed: %s", err)
+		source, err := c.GetRequired("htmlmin[%d].source", i)
+		if err != nil {
+			return err
+		}
+		dest, err := c.GetRequired("htmlmin[%d].dest", i)
+		if err != nil {
+			return err
+		}
+		if err := htmlcompressor(q.Context(), source, dest); err != nil {
+			return fmt.Errorf("html compress failed: %w", err)
 		}
 	}
 
 	return nil
 }
 
-func htmlcompressor(src, dest string) error {
+func htmlcompressor(ctx context.Context, src, dest string) error {
 	base := utils.PackagePath(selfPkg)
 	jarFile := filepath.Join(base, "htmlcompressor-1.5.3.jar")
 
@@ -38,10 +54,10 @@ func htmlcompressor(src, dest string) error {
 		"-o", dest,
 		"-r", src,
 	}
-	output, err := utils.Exec("java", args)
+	output, err := utils.Exec(ctx, "java", args)
 	if err != nil {
-		fmt.Println(output)
-		return fmt.Errorf("compressor error: %s", err)
+		fmt.Fprintln(utils.Output(ctx), output)
+		return fmt.Errorf("compressor error: %w", err)
 	}
 
 	return nil
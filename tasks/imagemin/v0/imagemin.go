@@ -1,8 +1,8 @@
 package v0
 
 import (
+	"context"
 	"fmt"
-	"log"
 	"os"
 	"path/filepath"
 
@@ -12,7 +12,13 @@ import (
 )
 
 func init() {
-	registry.NewTask("imagemin", 0, imagemin)
+	registry.NewTask("imagemin", 0, imagemin).
+		Describe("compress the images").
+		Requires("dist:prepare@0").
+		Inputs("temp/images/**").
+		Outputs("temp/images/**").
+		Tool("jpegtran", "-version").
+		Tool("optipng", "--version")
 }
 
 // Compress & optimize images. It does not run if the folder images does not
@@ -23,55 +29,57 @@ func imagemin(c *config.Config, q *registry.Queue) error {
 		if os.IsNotExist(err) {
 			return nil
 		}
-		return fmt.Errorf("stat images folder failed: %s", err)
+		return fmt.Errorf("stat images folder failed: %w", err)
 	}
 
-	if err := filepath.Walk(root, walkFn); err != nil {
-		return fmt.Errorf("walk images folder failed: %s", err)
+	if err := filepath.Walk(root, walkFn(q.Context())); err != nil {
+		return fmt.Errorf("walk images folder failed: %w", err)
 	}
 	return nil
 }
 
-func walkFn(path string, info os.FileInfo, err error) error {
-	if err != nil {
-		return fmt.Errorf("walk error: %s", err)
-	}
-	if info.IsDir() {
-		return nil
-	}
-
-	base := filepath.Join("temp", "images")
-	dest, err := filepath.Rel(base, path)
-	if err != nil {
-		return fmt.Errorf("rel failed: %s", err)
-	}
-	dest = filepath.Join("temp", "images", dest)
+func walkFn(ctx context.Context) filepath.WalkFunc {
+	return func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return fmt.Errorf("walk error: %w", err)
+		}
+		if info.IsDir() {
+			return nil
+		}
 
-	dir := filepath.Dir(dest)
-	if err := os.MkdirAll(dir, 0755); err != nil {
-		return fmt.Errorf("create folder failed (%s): %s", dir, err)
-	}
+		base := filepath.Join("temp", "images")
+		dest, err := filepath.Rel(base, path)
+		if err != nil {
+			return fmt.Errorf("rel failed: %w", err)
+		}
+		dest = filepath.Join("temp", "images", dest)
 
-	switch filepath.Ext(path) {
-	case ".jpg":
-		fallthrough
-	case ".jpeg":
-		if err := jpegtran(path, dest); err != nil {
-			return fmt.Errorf("jpeg optimization failed: %s", err)
+		dir := filepath.Dir(dest)
+		if err := utils.MkdirAll(dir); err != nil {
+			return fmt.Errorf("create folder failed (%s): %w", dir, err)
 		}
 
-	case ".png":
-		if err := optipng(path, dest); err != nil {
-			return fmt.Errorf("png optimization failed: %s", err)
+		switch filepath.Ext(path) {
+		case ".jpg":
+			fallthrough
+		case ".jpeg":
+			if err := jpegtran(ctx, path, dest); err != nil {
+				return fmt.Errorf("jpeg optimization failed: %w", err)
+			}
+
+		case ".png":
+			if err := optipng(ctx, path, dest); err != nil {
+				return fmt.Errorf("png optimization failed: %w", err)
+			}
 		}
-	}
 
-	return nil
+		return nil
+	}
 }
 
-func jpegtran(src, dest string) error {
+func jpegtran(ctx context.Context, src, dest string) error {
 	if *config.Verbose {
-		log.Printf("optimizing jpeg `%s`\n", src)
+		utils.Logf(ctx, "optimizing jpeg `%s`\n", src)
 	}
 
 	args := []string{
@@ -79,35 +87,35 @@ func jpegtran(src, dest string) error {
 		"-optimize", "-progressive",
 		"-outfile", dest, src,
 	}
-	output, err := utils.Exec("jpegtran", args)
+	output, err := utils.Exec(ctx, "jpegtran", args)
 	if err != nil {
-		fmt.Println(output)
-		return fmt.Errorf("jpeg optimizer error: %s", err)
+		fmt.Fprintln(utils.Output(ctx), output)
+		return fmt.Errorf("jpeg optimizer error: %w", err)
 	}
 
 	return nil
 }
 
-func optipng(src, dest string) error {
+func optipng(ctx context.Context, src, dest string) error {
 	if *config.Verbose {
-		log.Printf("optimizing png `%s`\n", src)
+		utils.Logf(ctx, "optimizing png `%s`\n", src)
 	}
 
 	args := []string{
 		"-strip", "all", "-clobber",
 		"-out", dest, src,
 	}
-	output, err := utils.Exec("optipng", args)
+	output, err := utils.Exec(ctx, "optipng", args)
 	if err != nil {
-		fmt.Println(output)
-		return fmt.Errorf("png optimizer error: %s", err)
+		fmt.Fprintln(utils.Output(ctx), output)
+		return fmt.Errorf("png optimizer error: %w", err)
 	}
 
-	if err := os.Remove(dest + ".bak"); err != nil {
+	if err := utils.Remove(dest + ".bak"); err != nil {
 		if os.IsNotExist(err) {
 			return nil
 		}
-		return fmt.Errorf("remove backup file failed: %s", err)
+		return fmt.Errorf("remove backup file failed: %w", err)
 	}
 
 	return nil
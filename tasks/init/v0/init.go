@@ -1,11 +1,11 @@
 package v0
 
 import (
+	"context"
 	"fmt"
 	"hash/crc32"
 	"io"
 	"io/ioutil"
-	"log"
 	"os"
 	"path/filepath"
 	"strings"
@@ -19,26 +19,23 @@ import (
 const selfPkg = "github.com/ernestokarim/cb/tasks/init/v0/templates"
 
 func init() {
-	registry.NewUserTask("init:*", 0, initTask)
+	registry.NewUserTask("init:*", 0, initTask).
+		Describe("create a new project from the template named after the colon").
+		RequiresFirst("update:check")
 }
 
 func initTask(c *config.Config, q *registry.Queue) error {
-	// Check for updates
-	if err := q.RunTasks(c, []string{"update:check"}); err != nil {
-		return err
-	}
-
 	// Retrieve the current working directory
 	cur, err := os.Getwd()
 	if err != nil {
-		return fmt.Errorf("getwd failed: %s", err)
+		return fmt.Errorf("getwd failed: %w", err)
 	}
 
 	// Go back one folder if we're inside the client one
 	if filepath.Base(cur) == "client" {
 		cur = filepath.Dir(cur)
 		if pathErr := os.Chdir(cur); pathErr != nil {
-			return fmt.Errorf("chdir to root folder failed: %s", err)
+			return fmt.Errorf("chdir to root folder failed: %w", err)
 		}
 	}
 
@@ -47,13 +44,13 @@ func initTask(c *config.Config, q *registry.Queue) error {
 	base := utils.PackagePath(filepath.Join(selfPkg, parts[1]))
 	appname := filepath.Base(cur)
 
-	if err := copyFiles(c, appname, base, cur, cur); err != nil {
-		return fmt.Errorf("copy files failed: %s", err)
+	if err := copyFiles(q.Context(), c, appname, base, cur, cur); err != nil {
+		return fmt.Errorf("copy files failed: %w", err)
 	}
 
 	// Post-init steps
-	if err := postInit(); err != nil {
-		return fmt.Errorf("post init failed: %s", err)
+	if err := postInit(q.Context()); err != nil {
+		return fmt.Errorf("post init failed: %w", err)
 	}
 
 	return nil
@@ -64,11 +61,11 @@ func initTask(c *config.Config, q *registry.Queue) error {
 //   - src: Source folder path
 //   - dest: Dest folder path
 //   - root: Root folder path
-func copyFiles(c *config.Config, appname, src, dest, root string) error {
+func copyFiles(ctx context.Context, c *config.Config, appname, src, dest, root string) error {
 	// Read the list of files of the source folder
 	files, err := ioutil.ReadDir(src)
 	if err != nil {
-		return fmt.Errorf("read folder failed (%s): %s", src, err)
+		return fmt.Errorf("read folder failed (%s): %w", src, err)
 	}
 
 	for _, entry := range files {
@@ -82,15 +79,15 @@ func copyFiles(c *config.Config, appname, src, dest, root string) error {
 			if err != nil {
 				// Unknown error
 				if !os.IsNotExist(err) {
-					return fmt.Errorf("stat dest failed: %s", err)
+					return fmt.Errorf("stat dest failed: %w", err)
 				}
 
 				// Create dest directory
 				if *config.Verbose {
-					log.Printf("create folder `%s`\n", dest)
+					utils.Logf(ctx, "create folder `%s`\n", dest)
 				}
-				if err := os.Mkdir(fulldest, entry.Mode()); err != nil {
-					return fmt.Errorf("create folder failed: %s", err)
+				if err := utils.MkdirAll(fulldest); err != nil {
+					return fmt.Errorf("create folder failed: %w", err)
 				}
 			} else if !info.IsDir() {
 				// Dest already present and not a folder
@@ -98,33 +95,33 @@ func copyFiles(c *config.Config, appname, src, dest, root string) error {
 			}
 
 			// Copy recursively the folder files
-			if err := copyFiles(c, appname, fullsrc, fulldest, root); err != nil {
-				return fmt.Errorf("recursive copy failed: %s", err)
+			if err := copyFiles(ctx, c, appname, fullsrc, fulldest, root); err != nil {
+				return fmt.Errorf("recursive copy failed: %w", err)
 			}
 		} else {
 			// Copy only one file
-			fulldest, err = copyFile(c, appname, fullsrc, fulldest, root)
+			fulldest, err = copyFile(ctx, c, appname, fullsrc, fulldest, root)
 			if err != nil {
-				return fmt.Errorf("copy file failed: %s", err)
+				return fmt.Errorf("copy file failed: %w", err)
 			}
 		}
-		if err := os.Chmod(fulldest, entry.Mode()); err != nil {
+		if err := utils.Chmod(fulldest, entry.Mode()); err != nil {
 			if os.IsNotExist(err) {
 				continue
 			}
-			return fmt.Errorf("change mode failed: %s", err)
+			return fmt.Errorf("change mode failed: %w", err)
 		}
 	}
 	return nil
 }
 
 // Copy a file, using templates if needed, from srcPath to destPath.
-func copyFile(c *config.Config, appname, srcPath, destPath, root string) (string, error) {
+func copyFile(ctx context.Context, c *config.Config, appname, srcPath, destPath, root string) (string, error) {
 	// Use a template for the file if needed
 	if strings.HasPrefix(filepath.Base(srcPath), "cbtmpl.") {
 		srcName, err := copyFileTemplate(appname, srcPath)
 		if err != nil {
-			return destPath, fmt.Errorf("copy file template failed: %s", err)
+			return destPath, fmt.Errorf("copy file template failed: %w", err)
 		}
 
 		srcPath = srcName
@@ -134,20 +131,20 @@ func copyFile(c *config.Config, appname, srcPath, destPath, root string) (string
 	// Open source file
 	src, err := os.Open(srcPath)
 	if err != nil {
-		return destPath, fmt.Errorf("open source file failed: %s", err)
+		return destPath, fmt.Errorf("open source file failed: %w", err)
 	}
 	defer src.Close()
 
 	// Path of the file relative to the root
 	relDest, err := filepath.Rel(root, destPath)
 	if err != nil {
-		return destPath, fmt.Errorf("cannot rel dest path: %s", err)
+		return destPath, fmt.Errorf("cannot rel dest path: %w", err)
 	}
 
 	if _, err := os.Stat(destPath); err != nil {
 		// Stat failed
 		if !os.IsNotExist(err) {
-			return destPath, fmt.Errorf("stat failed: %s", err)
+			return destPath, fmt.Errorf("stat failed: %w", err)
 		}
 
 		// If it doesn't exists, but the config file is present, we're updating
@@ -161,7 +158,7 @@ func copyFile(c *config.Config, appname, srcPath, destPath, root string) (string
 	} else {
 		// If it exists, but they're equal, ignore the copy of this file
 		if equal, err := compareFiles(srcPath, destPath); err != nil {
-			return destPath, fmt.Errorf("compare files failed: %s", err)
+			return destPath, fmt.Errorf("compare files failed: %w", err)
 		} else if equal {
 			return destPath, nil
 		}
@@ -174,7 +171,7 @@ func copyFile(c *config.Config, appname, srcPath, destPath, root string) (string
 	}
 
 	// Open dest file
-	dest, err := os.Create(destPath)
+	dest, err := utils.CreateFile(destPath)
 	if err != nil {
 		return destPath, err
 	}
@@ -182,10 +179,10 @@ func copyFile(c *config.Config, appname, srcPath, destPath, root string) (string
 
 	// Copy the file contents
 	if *config.Verbose {
-		log.Printf("copy file `%s`\n", relDest)
+		utils.Logf(ctx, "copy file `%s`\n", relDest)
 	}
 	if _, err := io.Copy(dest, src); err != nil {
-		return destPath, fmt.Errorf("copy file failed: %s", err)
+		return destPath, fmt.Errorf("copy file failed: %w", err)
 	}
 
 	return destPath, nil
@@ -194,12 +191,12 @@ func copyFile(c *config.Config, appname, srcPath, destPath, root string) (string
 func copyFileTemplate(appname, srcPath string) (string, error) {
 	t, err := template.New(filepath.Base(srcPath)).Delims(`{{%`, `%}}`).ParseFiles(srcPath)
 	if err != nil {
-		return "", fmt.Errorf("parse template failed: %s", err)
+		return "", fmt.Errorf("parse template failed: %w", err)
 	}
 
 	f, err := ioutil.TempFile("", "cb-init:")
 	if err != nil {
-		return "", fmt.Errorf("cannot create temp file: %s", err)
+		return "", fmt.Errorf("cannot create temp file: %w", err)
 	}
 	defer f.Close()
 
@@ -207,7 +204,7 @@ func copyFileTemplate(appname, srcPath string) (string, error) {
 		"AppName": appname,
 	}
 	if err := t.Execute(f, data); err != nil {
-		return "", fmt.Errorf("execute template failed: %s", err)
+		return "", fmt.Errorf("execute template failed: %w", err)
 	}
 
 	return f.Name(), nil
@@ -216,23 +213,23 @@ func copyFileTemplate(appname, srcPath string) (string, error) {
 func compareFiles(srcPath, destPath string) (bool, error) {
 	src, err := os.Open(srcPath)
 	if err != nil {
-		return false, fmt.Errorf("open source failed: %s", err)
+		return false, fmt.Errorf("open source failed: %w", err)
 	}
 	defer src.Close()
 
 	dest, err := os.Open(destPath)
 	if err != nil {
-		return false, fmt.Errorf("open dest failed: %s", err)
+		return false, fmt.Errorf("open dest failed: %w", err)
 	}
 	defer dest.Close()
 
 	srcContents, err := ioutil.ReadAll(src)
 	if err != nil {
-		return false, fmt.Errorf("read source failed: %s", err)
+		return false, fmt.Errorf("read source failed: %w", err)
 	}
 	destContents, err := ioutil.ReadAll(dest)
 	if err != nil {
-		return false, fmt.Errorf("read dest failed: %s", err)
+		return false, fmt.Errorf("read dest failed: %w", err)
 	}
 
 	contentsHash := fmt.Sprintf("%x", crc32.ChecksumIEEE(srcContents))
@@ -241,12 +238,12 @@ func compareFiles(srcPath, destPath string) (bool, error) {
 	return srcHash == contentsHash, nil
 }
 
-func postInit() error {
+func postInit(ctx context.Context) error {
 	// Test if the post-init file exists
 	if _, err := os.Stat("post-init.sh"); err != nil {
 		if os.IsNotExist(err) {
 			if *config.Verbose {
-				log.Println("post-init.sh file doesn't exist")
+				utils.Logf(ctx, "post-init.sh file doesn't exist\n")
 			}
 			return nil
 		}
@@ -254,12 +251,12 @@ func postInit() error {
 	}
 
 	if *config.Verbose {
-		log.Println("running post-init.sh file")
+		utils.Logf(ctx, "running post-init.sh file\n")
 	}
 
 	// Run it
-	if err := utils.ExecCopyOutput("bash", []string{"./post-init.sh"}); err != nil {
-		return fmt.Errorf("post init exec failed: %s", err)
+	if err := utils.ExecCopyOutput(ctx, "bash", []string{"./post-init.sh"}); err != nil {
+		return fmt.Errorf("post init exec failed: %w", err)
 	}
 
 	return nil
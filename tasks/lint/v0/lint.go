@@ -13,17 +13,19 @@ var (
 )
 
 func init() {
-	registry.NewUserTask("lint", 0, lint)
-	registry.NewUserTask("fixlint", 0, fixlint)
+	registry.NewUserTask("lint", 0, lint).
+		Describe("check the style of the scripts")
+	registry.NewUserTask("fixlint", 0, fixlint).
+		Describe("fix the style of the scripts")
 }
 
 func lint(c *config.Config, q *registry.Queue) error {
 	for _, folder := range folders {
 		args := []string{"--strict", "-r", folder, "-e", "app/scripts/vendor"}
-		output, err := utils.Exec("gjslint", args)
+		output, err := utils.Exec(q.Context(), "gjslint", args)
 		if err != nil {
-			fmt.Println(output)
-			return fmt.Errorf("linter error: %s", err)
+			fmt.Fprintln(utils.Output(q.Context()), output)
+			return fmt.Errorf("linter error: %w", err)
 		}
 	}
 	return nil
@@ -32,10 +34,10 @@ func lint(c *config.Config, q *registry.Queue) error {
 func fixlint(c *config.Config, q *registry.Queue) error {
 	for _, folder := range folders {
 		args := []string{"--strict", "-r", folder, "-e", "app/scripts/vendor"}
-		output, err := utils.Exec("fixjsstyle", args)
+		output, err := utils.Exec(q.Context(), "fixjsstyle", args)
 		if err != nil {
-			fmt.Println(output)
-			return fmt.Errorf("fixer error: %s", err)
+			fmt.Fprintln(utils.Output(q.Context()), output)
+			return fmt.Errorf("fixer error: %w", err)
 		}
 	}
 	return nil
@@ -16,14 +16,21 @@ var (
 )
 
 func init() {
-	registry.NewTask("minignore", 0, minignore)
+	registry.NewTask("minignore", 0, minignore).
+		Describe("remove the blocks of the base file that should not be minified").
+		Requires("dist:prepare@0").
+		Reads("<paths.base>")
 }
 
 func minignore(c *config.Config, q *registry.Queue) error {
-	base := filepath.Join("temp", filepath.Base(c.GetRequired("paths.base")))
+	base, err := c.GetRequired("paths.base")
+	if err != nil {
+		return err
+	}
+	base = filepath.Join("temp", filepath.Base(base))
 	lines, err := utils.ReadLines(base)
 	if err != nil {
-		return fmt.Errorf("read base html failed: %s", err)
+		return fmt.Errorf("read base html failed: %w", err)
 	}
 	for i, line := range lines {
 		if strings.Contains(line, "<!-- min -->") {
@@ -41,7 +48,7 @@ func minignore(c *config.Config, q *registry.Queue) error {
 	}
 
 	if err := utils.WriteFile(base, strings.Join(lines, "")); err != nil {
-		return fmt.Errorf("write file failed: %s", err)
+		return fmt.Errorf("write file failed: %w", err)
 	}
 
 	return nil
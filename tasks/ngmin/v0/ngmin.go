@@ -1,8 +1,8 @@
 package v0
 
 import (
+	"context"
 	"fmt"
-	"log"
 	"os"
 	"path/filepath"
 	"regexp"
@@ -19,107 +19,111 @@ var (
 )
 
 func init() {
-	registry.NewTask("ngmin", 0, ngmin)
+	registry.NewTask("ngmin", 0, ngmin).
+		Describe("annotate the angular injections of the scripts").
+		Requires("minignore@0")
 }
 
 func ngmin(c *config.Config, q *registry.Queue) error {
 	scripts := filepath.Join("temp", "scripts")
-	if err := filepath.Walk(scripts, walkFn); err != nil {
-		return fmt.Errorf("scripts walk failed: %s", err)
+	if err := filepath.Walk(scripts, walkFn(q.Context())); err != nil {
+		return fmt.Errorf("scripts walk failed: %w", err)
 	}
 	return nil
 }
 
-func walkFn(path string, info os.FileInfo, err error) error {
-	if err != nil {
-		return fmt.Errorf("walk failed: %s", err)
-	}
-	if info.IsDir() && filepath.Base(path) == "vendor" {
-		return filepath.SkipDir
-	}
-	if info.IsDir() {
-		return nil
-	}
-	if filepath.Ext(path) != ".js" {
-		return nil
-	}
+func walkFn(ctx context.Context) filepath.WalkFunc {
+	return func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return fmt.Errorf("walk failed: %w", err)
+		}
+		if info.IsDir() && filepath.Base(path) == "vendor" {
+			return filepath.SkipDir
+		}
+		if info.IsDir() {
+			return nil
+		}
+		if filepath.Ext(path) != ".js" {
+			return nil
+		}
 
-	lines, err := utils.ReadLines(path)
-	if err != nil {
-		return fmt.Errorf("read source failed: %s", err)
-	}
+		lines, err := utils.ReadLines(path)
+		if err != nil {
+			return fmt.Errorf("read source failed: %w", err)
+		}
 
-	newlines := []string{}
-	for i, line := range lines {
-		// Functions
-		funcs := []string{"factory", "directive", "config", "controller", "run"}
-		used := false
-		for _, f := range funcs {
-			if !strings.HasPrefix(line, "m."+f+"(") {
-				continue
-			}
+		newlines := []string{}
+		for i, line := range lines {
+			// Functions
+			funcs := []string{"factory", "directive", "config", "controller", "run"}
+			used := false
+			for _, f := range funcs {
+				if !strings.HasPrefix(line, "m."+f+"(") {
+					continue
+				}
 
-			// Easy alert of a common error
-			if line[len(line)-2] == ' ' {
-				return fmt.Errorf("%s:%d - final space", path, i+1)
-			}
+				// Easy alert of a common error
+				if line[len(line)-2] == ' ' {
+					return fmt.Errorf("%s:%d - final space", path, i+1)
+				}
 
-			// Line continues in the next one
-			if line[len(line)-2] == ',' {
-				l := line
-				i++
-				for {
-					l = fmt.Sprintf("%s %s\n", l[:len(l)-1], strings.TrimSpace(lines[i]))
-					lines[i] = ""
+				// Line continues in the next one
+				if line[len(line)-2] == ',' {
+					l := line
 					i++
-					if i >= len(lines) {
-						return fmt.Errorf("%s:%d - cannot found function start", path, i)
+					for {
+						l = fmt.Sprintf("%s %s\n", l[:len(l)-1], strings.TrimSpace(lines[i]))
+						lines[i] = ""
+						i++
+						if i >= len(lines) {
+							return fmt.Errorf("%s:%d - cannot found function start", path, i)
+						}
+						if strings.Contains(l, "{") {
+							line = l
+							break
+						}
 					}
-					if strings.Contains(l, "{") {
-						line = l
+				}
+
+				// Annotate the function
+				ls, err := funcAnnotations(ctx, path, i+1, line)
+				if err != nil {
+					return fmt.Errorf("annotation failed: %w", err)
+				}
+				newlines = append(newlines, ls...)
+
+				// Closing of functions
+				found := false
+				for j := i; j < len(lines); j++ {
+					if lines[j] == "});\n" {
+						found = true
+						lines[j] = "}]);\n"
 						break
 					}
 				}
-			}
-
-			// Annotate the function
-			ls, err := funcAnnotations(path, i+1, line)
-			if err != nil {
-				return fmt.Errorf("annotation failed: %s", err)
-			}
-			newlines = append(newlines, ls...)
-
-			// Closing of functions
-			found := false
-			for j := i; j < len(lines); j++ {
-				if lines[j] == "});\n" {
-					found = true
-					lines[j] = "}]);\n"
-					break
+				if !found {
+					return fmt.Errorf("%s:%d - close brace not found", path, i+1)
 				}
+
+				used = true
+				break
 			}
-			if !found {
-				return fmt.Errorf("%s:%d - close brace not found", path, i+1)
+			if used {
+				continue
 			}
 
-			used = true
-			break
-		}
-		if used {
-			continue
+			newlines = append(newlines, line)
 		}
 
-		newlines = append(newlines, line)
-	}
+		if err := utils.WriteFile(path, strings.Join(newlines, "")); err != nil {
+			return fmt.Errorf("write base html failed: %w", err)
+		}
 
-	if err := utils.WriteFile(path, strings.Join(newlines, "")); err != nil {
-		return fmt.Errorf("write base html failed: %s", err)
+		return nil
 	}
-
-	return nil
 }
 
-func funcAnnotations(file string, n int, line string) ([]string, error) {
+func funcAnnotations(ctx context.Context, file string, n int, line string) ([]string, error) {
 	match := funcRe.FindStringSubmatch(line)
 	if match == nil {
 		return nil, fmt.Errorf("%s:%d - incorrect function func", file, n)
@@ -127,9 +131,9 @@ func funcAnnotations(file string, n int, line string) ([]string, error) {
 
 	if *config.Verbose {
 		if match[3] == "" {
-			log.Printf("instrumenting function `config` - %s:%d\n", file, n)
+			utils.Logf(ctx, "instrumenting function `config` - %s:%d\n", file, n)
 		} else {
-			log.Printf("instrumenting function `%s` - %s:%d\n", match[4], file, n)
+			utils.Logf(ctx, "instrumenting function `%s` - %s:%d\n", match[4], file, n)
 		}
 	}
 
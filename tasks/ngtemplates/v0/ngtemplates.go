@@ -1,9 +1,9 @@
 package v0
 
 import (
+	"context"
 	"fmt"
 	"io/ioutil"
-	"log"
 	"os"
 	"path/filepath"
 	"strings"
@@ -14,29 +14,38 @@ import (
 )
 
 func init() {
-	registry.NewTask("ngtemplates", 0, ngtemplates)
+	registry.NewTask("ngtemplates", 0, ngtemplates).
+		Describe("append the angular templates to the scripts").
+		Requires("htmlmin@0", "concat@0").
+		Reads("<ngtemplates:list>", "<ngtemplates[].append>", "<ngtemplates[].files:list>")
 }
 
 func ngtemplates(c *config.Config, q *registry.Queue) error {
-	count := c.CountRequired("ngtemplates")
-	for i := 0; i < count; i++ {
-		append := c.GetRequired("ngtemplates[%d].append", i)
-		files := c.GetListRequired("ngtemplates[%d].files", i)
+	ctx := q.Context()
+	var entries []struct {
+		Append string   `config:"append,required"`
+		Files  []string `config:"files,required"`
+	}
+	if err := c.Decode(&entries, "ngtemplates"); err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		append, files := entry.Append, entry.Files
 
-		templates, err := readTemplates(files)
+		templates, err := readTemplates(ctx, files)
 		if err != nil {
-			return fmt.Errorf("cannot read templates: %s", err)
+			return fmt.Errorf("cannot read templates: %w", err)
 		}
 
-		if err = writeTemplates(append, templates); err != nil {
-			return fmt.Errorf("cannot save template file: %s", err)
+		if err = writeTemplates(ctx, append, templates); err != nil {
+			return fmt.Errorf("cannot save template file: %w", err)
 		}
 	}
 
 	return nil
 }
 
-func readTemplates(paths []string) (map[string]string, error) {
+func readTemplates(ctx context.Context, paths []string) (map[string]string, error) {
 	rootPath := "temp"
 	templates := map[string]string{}
 
@@ -48,7 +57,7 @@ func readTemplates(paths []string) (map[string]string, error) {
 		// Rel path and ignore already cached templates
 		rel, err := filepath.Rel(rootPath, path)
 		if err != nil {
-			return fmt.Errorf("cannot rel path: %s", err)
+			return fmt.Errorf("cannot rel path: %w", err)
 		}
 		if templates[rel] != "" {
 			return nil
@@ -57,33 +66,31 @@ func readTemplates(paths []string) (map[string]string, error) {
 		// Read template contents
 		contents, err := ioutil.ReadFile(path)
 		if err != nil {
-			return fmt.Errorf("read file failed: %s", err)
+			return fmt.Errorf("read file failed: %w", err)
 		}
 
 		if *config.Verbose {
-			log.Printf("registering template `%s`\n", rel)
+			utils.Logf(ctx, "registering template `%s`\n", rel)
 		}
 		templates[rel] = string(contents)
 
 		return nil
 	}
-	for _, path := range paths {
-		path = filepath.Join(rootPath, path)
-		if err := utils.NewWalker(path).Walk(walkFn); err != nil {
-			return nil, fmt.Errorf("walk path `%s` failed: %s", path, err)
-		}
+	paths = utils.JoinPatterns(rootPath, paths)
+	if err := utils.NewWalker(paths...).Walk(walkFn); err != nil {
+		return nil, fmt.Errorf("walk paths %v failed: %w", paths, err)
 	}
 
 	return templates, nil
 }
 
-func writeTemplates(filename string, templates map[string]string) error {
+func writeTemplates(ctx context.Context, filename string, templates map[string]string) error {
 	dest := filepath.Join("temp", filename)
 
 	// Open file
-	f, err := os.OpenFile(dest, os.O_RDWR|os.O_APPEND, 0)
+	f, err := utils.AppendFile(dest)
 	if err != nil {
-		return fmt.Errorf("open templates dest failed: %s", err)
+		return fmt.Errorf("open templates dest failed: %w", err)
 	}
 	defer f.Close()
 
@@ -99,7 +106,7 @@ func writeTemplates(filename string, templates map[string]string) error {
 	fmt.Fprintf(f, "}]);")
 
 	if *config.Verbose {
-		log.Printf("writing templates to `%s`\n", dest)
+		utils.Logf(ctx, "writing templates to `%s`\n", dest)
 	}
 
 	return nil
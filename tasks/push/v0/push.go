@@ -1,11 +1,11 @@
 package v0
 
 import (
+	"context"
 	"crypto/sha1"
 	"encoding/gob"
 	"fmt"
 	"io/ioutil"
-	"log"
 	"os"
 	"path/filepath"
 	"sort"
@@ -20,61 +20,64 @@ import (
 const selfPkg = "github.com/ernestokarim/cb/tasks/push/v0/scripts"
 
 func init() {
-	registry.NewUserTask("push", 0, push)
+	registry.NewUserTask("push", 0, push).
+		Describe("upload the modified files of the deploy folder by FTP").
+		Args("<user>").
+		Reads("<push>")
 }
 
 func push(c *config.Config, q *registry.Queue) error {
+	ctx := q.Context()
 	scriptsPath := utils.PackagePath(selfPkg)
-	host := c.GetRequired("push")
+	host, err := c.GetRequired("push")
+	if err != nil {
+		return err
+	}
 
 	// FTP User & password
-	user := q.NextTask()
-	if user == "" {
-		return fmt.Errorf("ftp user required as the first argument")
-	}
-	q.RemoveNextTask()
+	user := q.Args().String("user")
 
 	password, err := gopass.GetPass(fmt.Sprintf("Enter \"%s\" password: ", user))
 	if err != nil {
-		return fmt.Errorf("cannot read password: %s", err)
+		return fmt.Errorf("cannot read password: %w", err)
 	}
 	if password == "" {
 		return fmt.Errorf("ftp password is required")
 	}
 
 	// Hash local files
-	log.Printf("Hashing local files... ")
+	utils.Logf(ctx, "Hashing local files... ")
 	localHashes, err := hashLocalFiles()
 	if err != nil {
-		return fmt.Errorf("hash local files failed: %s", err)
+		return fmt.Errorf("hash local files failed: %w", err)
 	}
-	log.Printf("Hashing local files... %s[SUCCESS]%s\n", colors.Green, colors.Reset)
+	utils.Logf(ctx, "Hashing local files... %s[SUCCESS]%s\n", colors.Green, colors.Reset)
 
 	// Hash remote files
-	log.Printf("Hashing remote files... ")
-	remoteHashes, err := retrieveRemoteHashes(scriptsPath, user, password, host)
+	utils.Logf(ctx, "Hashing remote files... ")
+	remoteHashes, err := retrieveRemoteHashes(ctx, scriptsPath, user, password, host)
 	if err != nil {
-		return fmt.Errorf("retrieve remote hashes failed: %s", err)
+		return fmt.Errorf("retrieve remote hashes failed: %w", err)
 	}
-	log.Printf("Hashing remote files... %s[SUCCESS]%s\n", colors.Green, colors.Reset)
+	utils.Logf(ctx, "Hashing remote files... %s[SUCCESS]%s\n", colors.Green, colors.Reset)
 
 	if err := saveLocalHashes(localHashes); err != nil {
-		return fmt.Errorf("save local hashes failed: %s", err)
+		return fmt.Errorf("save local hashes failed: %w", err)
 	}
 
 	// Prepare FTP commands
-	log.Printf("Preparing FTP commands... ")
+	utils.Logf(ctx, "Preparing FTP commands... ")
 	if err := prepareFTPCommands(localHashes, remoteHashes); err != nil {
-		return fmt.Errorf("prepare FTP commands failed: %s", err)
+		return fmt.Errorf("prepare FTP commands failed: %w", err)
 	}
-	log.Printf("Preparing FTP commands... %s[SUCCESS]%s\n", colors.Green, colors.Reset)
+	utils.Logf(ctx, "Preparing FTP commands... %s[SUCCESS]%s\n", colors.Green, colors.Reset)
 
 	// Upload files
-	log.Printf("Uploading files... ")
-	if err := uploadFiles(scriptsPath, user, password, host); err != nil {
-		return fmt.Errorf("uploading files failed: %s", err)
+	utils.Logf(ctx, "Uploading files... ")
+	if err := uploadFiles(ctx, scriptsPath, user, password, host); err != nil {
+		return fmt.Errorf("uploading files failed: %w", err)
 	}
-	log.Printf("Uploading files... %s[SUCCESS]%s\n", colors.Green, colors.Reset)
+	utils.Logf(ctx, "Uploading files... %s[SUCCESS]%s\n", colors.Green, colors.Reset)
 
 	return nil
 }
@@ -92,24 +95,24 @@ func hashLocalFiles() (map[string]string, error) {
 		if !info.IsDir() {
 			f, err := os.Open(path)
 			if err != nil {
-				return fmt.Errorf("open file failed: %s", err)
+				return fmt.Errorf("open file failed: %w", err)
 			}
 			defer f.Close()
 			content, err := ioutil.ReadAll(f)
 			if err != nil {
-				return fmt.Errorf("read file failed: %s", err)
+				return fmt.Errorf("read file failed: %w", err)
 			}
 			if _, err := h.Write(content); err != nil {
-				return fmt.Errorf("hash failed: %s", err)
+				return fmt.Errorf("hash failed: %w", err)
 			}
 		}
 		if _, err := h.Write([]byte(fmt.Sprintf("%s", info.Mode()))); err != nil {
-			return fmt.Errorf("hash perms failed: %s", err)
+			return fmt.Errorf("hash perms failed: %w", err)
 		}
 
 		rel, err := filepath.Rel(rootPath, path)
 		if err != nil {
-			return fmt.Errorf("rel path failed: %s", err)
+			return fmt.Errorf("rel path failed: %w", err)
 		}
 
 		hashes[rel] = fmt.Sprintf("%x", h.Sum(nil))
@@ -117,18 +120,18 @@ func hashLocalFiles() (map[string]string, error) {
 		return nil
 	}
 	if err := filepath.Walk(rootPath, walkFn); err != nil {
-		return nil, fmt.Errorf("hash walk failed: %s", err)
+		return nil, fmt.Errorf("hash walk failed: %w", err)
 	}
 
 	return hashes, nil
 }
 
-func retrieveRemoteHashes(scriptsPath, user, password, host string) (map[string]string, error) {
+func retrieveRemoteHashes(ctx context.Context, scriptsPath, user, password, host string) (map[string]string, error) {
 	args := []string{user, password, host}
-	output, err := utils.Exec(filepath.Join(scriptsPath, "download-hashes.sh"), args)
+	output, err := utils.Exec(ctx, filepath.Join(scriptsPath, "download-hashes.sh"), args)
 	if err != nil {
-		fmt.Println(output)
-		return nil, fmt.Errorf("download hashes script failed: %s", err)
+		fmt.Fprintln(utils.Output(ctx), output)
+		return nil, fmt.Errorf("download hashes script failed: %w", err)
 	}
 
 	f, err := os.Open("temp/hashes")
@@ -136,21 +139,21 @@ func retrieveRemoteHashes(scriptsPath, user, password, host string) (map[string]
 		if os.IsNotExist(err) {
 			return nil, nil
 		}
-		return nil, fmt.Errorf("stat hashes failed: %s", err)
+		return nil, fmt.Errorf("stat hashes failed: %w", err)
 	}
 
 	hashes := map[string]string{}
 	if err := gob.NewDecoder(f).Decode(&hashes); err != nil {
-		return nil, fmt.Errorf("cannot decode hashes: %s", err)
+		return nil, fmt.Errorf("cannot decode hashes: %w", err)
 	}
 
 	return hashes, nil
 }
 
-func uploadFiles(scriptsPath, user, password, host string) error {
+func uploadFiles(ctx context.Context, scriptsPath, user, password, host string) error {
 	args := []string{user, password, host}
-	if err := utils.ExecCopyOutput(filepath.Join(scriptsPath, "upload.sh"), args); err != nil {
-		return fmt.Errorf("upload script failed: %s", err)
+	if err := utils.ExecCopyOutput(ctx, filepath.Join(scriptsPath, "upload.sh"), args); err != nil {
+		return fmt.Errorf("upload script failed: %w", err)
 	}
 
 	return nil
@@ -161,9 +164,9 @@ func prepareFTPCommands(localHashes, remoteHashes map[string]string) error {
 	changed := 0
 
 	// Prepare commands file
-	f, err := os.Create("temp/upload-commands")
+	f, err := utils.CreateFile("temp/upload-commands")
 	if err != nil {
-		return fmt.Errorf("cannot create commands file: %s", err)
+		return fmt.Errorf("cannot create commands file: %w", err)
 	}
 	defer f.Close()
 
@@ -178,7 +181,7 @@ func prepareFTPCommands(localHashes, remoteHashes map[string]string) error {
 		// Prepare paths
 		folder, err := filepath.Rel(rootPath, filepath.Dir(path))
 		if err != nil {
-			return fmt.Errorf("cannot rel path: %s", err)
+			return fmt.Errorf("cannot rel path: %w", err)
 		}
 		basename := filepath.Base(path)
 		basepath := filepath.Join("..", "deploy", path)
@@ -205,7 +208,7 @@ func prepareFTPCommands(localHashes, remoteHashes map[string]string) error {
 		return nil
 	}
 	if err := filepath.Walk(rootPath, walkFn); err != nil {
-		return fmt.Errorf("hash walk failed: %s", err)
+		return fmt.Errorf("hash walk failed: %w", err)
 	}
 
 	mk := make([]string, len(remoteHashes))
@@ -235,14 +238,14 @@ func prepareFTPCommands(localHashes, remoteHashes map[string]string) error {
 }
 
 func saveLocalHashes(hashes map[string]string) error {
-	f, err := os.Create("temp/hashes")
+	f, err := utils.CreateFile("temp/hashes")
 	if err != nil {
-		return fmt.Errorf("create file failed: %s", err)
+		return fmt.Errorf("create file failed: %w", err)
 	}
 	defer f.Close()
 
 	if err := gob.NewEncoder(f).Encode(hashes); err != nil {
-		return fmt.Errorf("go failed encoder failed: %s", err)
+		return fmt.Errorf("go failed encoder failed: %w", err)
 	}
 	return nil
 }
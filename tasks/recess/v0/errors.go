@@ -0,0 +1,34 @@
+package v0
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/ernestokarim/cb/utils"
+)
+
+// Less errors: `ParseError: Unrecognised input in app/styles/a.less on line 3, column 5:`
+var lessErrorRe = regexp.MustCompile(`(\w*Error): (.+?) in (\S+) on line (\d+), column (\d+)`)
+
+// parseError extracts the message & the location of the error from the
+// output of the compiler. If they can't be found it points to the source
+// compiled, with the first line of the output.
+func parseError(output, src string) *utils.Problem {
+	p := &utils.Problem{Tool: "recess", File: src}
+	if m := lessErrorRe.FindStringSubmatch(output); m != nil {
+		p.Message = m[1] + ": " + m[2]
+		p.File = m[3]
+		p.Line, _ = strconv.Atoi(m[4])
+		p.Column, _ = strconv.Atoi(m[5])
+		return p
+	}
+
+	for _, line := range strings.Split(output, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			p.Message = line
+			break
+		}
+	}
+	return p
+}
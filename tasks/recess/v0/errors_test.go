@@ -0,0 +1,45 @@
+package v0
+
+import (
+	"testing"
+
+	"github.com/ernestokarim/cb/utils"
+)
+
+func TestParseError(t *testing.T) {
+	tests := []struct {
+		output string
+		want   utils.Problem
+	}{
+		{
+			"\nParseError: Unrecognised input in app/styles/_vars.less on line 3, column 5:\n" +
+				"2 @a: 1;\n3 @b: ;\n",
+			utils.Problem{
+				Tool:    "recess",
+				File:    "app/styles/_vars.less",
+				Line:    3,
+				Column:  5,
+				Message: "ParseError: Unrecognised input",
+			},
+		},
+		{
+			"Error: variable @c is undefined in app/styles/main.less on line 10, column 1:\n",
+			utils.Problem{
+				Tool:    "recess",
+				File:    "app/styles/main.less",
+				Line:    10,
+				Column:  1,
+				Message: "Error: variable @c is undefined",
+			},
+		},
+		{
+			"\n  recess: command not found\n",
+			utils.Problem{Tool: "recess", File: "app/styles/main.less", Message: "recess: command not found"},
+		},
+	}
+	for _, test := range tests {
+		if got := parseError(test.output, "app/styles/main.less"); *got != test.want {
+			t.Errorf("parseError(%q) = %+v, want %+v", test.output, *got, test.want)
+		}
+	}
+}
@@ -2,7 +2,6 @@ package v0
 
 import (
 	"fmt"
-	"log"
 	"path/filepath"
 
 	"github.com/ernestokarim/cb/config"
@@ -13,16 +12,27 @@ import (
 func init() {
 	registry.NewTask("recess", 0, func(c *config.Config, q *registry.Queue) error {
 		return execRecess(c, q, "dev")
-	})
+	}).Describe("compile the less styles for development").
+		Requires("clean@0").
+		Reads("<recess:list>", "<recess[].source>", "<recess[].dest>").
+		Inputs("app/styles/${recess[].source}", "app/styles/**/*.less").
+		Outputs("temp/styles/${recess[].dest}").
+		Tool("recess", "--version")
 	registry.NewTask("recess:build", 0, func(c *config.Config, q *registry.Queue) error {
 		return execRecess(c, q, "prod")
-	})
+	}).Describe("compile & compress the less styles").
+		Requires("dist:prepare@0").
+		Reads("<recess:list>", "<recess[].source>", "<recess[].dest>").
+		Inputs("temp/styles/${recess[].source}", "temp/styles/**/*.less").
+		Outputs("temp/styles/${recess[].dest}").
+		Tool("recess", "--version")
 }
 
 func execRecess(c *config.Config, q *registry.Queue, mode string) error {
+	ctx := q.Context()
 	files, err := lessFromConfig(c, mode)
 	if err != nil {
-		return fmt.Errorf("read config failed: %s", err)
+		return fmt.Errorf("read config failed: %w", err)
 	}
 
 	var flag string
@@ -34,18 +44,19 @@ func execRecess(c *config.Config, q *registry.Queue, mode string) error {
 
 	for _, file := range files {
 		args := []string{flag, "--stripColors", file.Src}
-		output, err := utils.Exec("recess", args)
+		output, err := utils.Exec(ctx, "recess", args)
 		if err != nil {
-			fmt.Println(output)
-			return fmt.Errorf("tool error: %s", err)
+			fmt.Fprintln(utils.Output(ctx), output)
+			utils.ReportProblem(ctx, parseError(output, file.Src))
+			return fmt.Errorf("tool error: %w", err)
 		}
 
 		if err := utils.WriteFile(file.Dest, output); err != nil {
-			return fmt.Errorf("write file failed: %s", err)
+			return fmt.Errorf("write file failed: %w", err)
 		}
 
 		if *config.Verbose {
-			log.Printf("created file %s\n", file.Dest)
+			utils.Logf(ctx, "created file %s\n", file.Dest)
 		}
 	}
 
@@ -53,7 +64,8 @@ func execRecess(c *config.Config, q *registry.Queue, mode string) error {
 }
 
 type lessFile struct {
-	Src, Dest string
+	Src  string `config:"source,required"`
+	Dest string `config:"dest,required"`
 }
 
 func lessFromConfig(c *config.Config, mode string) ([]*lessFile, error) {
@@ -65,11 +77,12 @@ func lessFromConfig(c *config.Config, mode string) ([]*lessFile, error) {
 	}
 
 	files := []*lessFile{}
-	size := c.CountRequired("recess")
-	for i := 0; i < size; i++ {
-		src := filepath.Join(from, "styles", c.GetRequired("recess[%d].source", i))
-		dest := filepath.Join("temp", "styles", c.GetRequired("recess[%d].dest", i))
-		files = append(files, &lessFile{src, dest})
+	if err := c.Decode(&files, "recess"); err != nil {
+		return nil, err
+	}
+	for _, file := range files {
+		file.Src = filepath.Join(from, "styles", file.Src)
+		file.Dest = filepath.Join("temp", "styles", file.Dest)
 	}
 	return files, nil
 }
@@ -0,0 +1,53 @@
+package v0
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/ernestokarim/cb/utils"
+)
+
+var (
+	// Ruby sass: `on line 3 of app/styles/main.scss`
+	rubyLocationRe = regexp.MustCompile(`on line (\d+) of (\S+)`)
+
+	// Dart sass: `  app/styles/main.scss 3:4  root stylesheet`
+	dartLocationRe = regexp.MustCompile(`(?m)^\s+(\S+) (\d+):(\d+)\s`)
+)
+
+// parseError extracts the message & the location of the error from the
+// output of the compiler. If they can't be found it points to the source
+// compiled, with the first line of the output.
+func parseError(output, src string) *utils.Problem {
+	p := &utils.Problem{
+		Tool:    "sass",
+		File:    src,
+		Message: errorMessage(output),
+	}
+	if m := rubyLocationRe.FindStringSubmatch(output); m != nil {
+		p.Line, _ = strconv.Atoi(m[1])
+		p.File = m[2]
+	} else if m := dartLocationRe.FindStringSubmatch(output); m != nil {
+		p.File = m[1]
+		p.Line, _ = strconv.Atoi(m[2])
+		p.Column, _ = strconv.Atoi(m[3])
+	}
+	return p
+}
+
+func errorMessage(output string) string {
+	first := ""
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		for _, prefix := range []string{"Error: ", "Syntax error: "} {
+			if strings.HasPrefix(line, prefix) {
+				return strings.TrimPrefix(line, prefix)
+			}
+		}
+		if first == "" {
+			first = line
+		}
+	}
+	return first
+}
@@ -0,0 +1,47 @@
+package v0
+
+import (
+	"testing"
+
+	"github.com/ernestokarim/cb/utils"
+)
+
+func TestParseError(t *testing.T) {
+	tests := []struct {
+		output string
+		want   utils.Problem
+	}{
+		{
+			"Syntax error: Invalid CSS after \"a {\": expected \"}\", was \"\"\n" +
+				"        on line 3 of app/styles/_mixins.scss\n" +
+				"        from line 1 of app/styles/main.scss\n",
+			utils.Problem{
+				Tool:    "sass",
+				File:    "app/styles/_mixins.scss",
+				Line:    3,
+				Message: "Invalid CSS after \"a {\": expected \"}\", was \"\"",
+			},
+		},
+		{
+			"Error: expected \"}\".\n  ╷\n3 │ a {\n  │    ^\n  ╵\n" +
+				"  app/styles/_mixins.scss 3:4  @import\n" +
+				"  app/styles/main.scss 1:9     root stylesheet\n",
+			utils.Problem{
+				Tool:    "sass",
+				File:    "app/styles/_mixins.scss",
+				Line:    3,
+				Column:  4,
+				Message: "expected \"}\".",
+			},
+		},
+		{
+			"\nsass: command not found\n",
+			utils.Problem{Tool: "sass", File: "app/styles/main.scss", Message: "sass: command not found"},
+		},
+	}
+	for _, test := range tests {
+		if got := parseError(test.output, "app/styles/main.scss"); *got != test.want {
+			t.Errorf("parseError(%q) = %+v, want %+v", test.output, *got, test.want)
+		}
+	}
+}
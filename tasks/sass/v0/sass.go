@@ -2,7 +2,6 @@ package v0
 
 import (
 	"fmt"
-	"log"
 	"path/filepath"
 
 	"github.com/ernestokarim/cb/config"
@@ -13,16 +12,27 @@ import (
 func init() {
 	registry.NewTask("sass", 0, func(c *config.Config, q *registry.Queue) error {
 		return execSass(c, q, "dev")
-	})
+	}).Describe("compile the sass styles for development").
+		Requires("clean@0").
+		Reads("<sass:list>", "<sass[].source>", "<sass[].dest>", "[closure.library]").
+		InputsFrom(sassInputs("dev")).
+		Outputs("temp/styles/${sass[].dest}").
+		Tool("sass", "--version")
 	registry.NewTask("sass:build", 0, func(c *config.Config, q *registry.Queue) error {
 		return execSass(c, q, "prod")
-	})
+	}).Describe("compile & compress the sass styles").
+		Requires("dist:prepare@0").
+		Reads("<sass:list>", "<sass[].source>", "<sass[].dest>", "[closure.library]").
+		InputsFrom(sassInputs("prod")).
+		Outputs("temp/styles/${sass[].dest}").
+		Tool("sass", "--version")
 }
 
 func execSass(c *config.Config, q *registry.Queue, mode string) error {
+	ctx := q.Context()
 	files, err := sassFromConfig(c, mode)
 	if err != nil {
-		return fmt.Errorf("read config failed")
+		return fmt.Errorf("read config failed: %w", err)
 	}
 	for _, file := range files {
 		args := []string{
@@ -34,43 +44,83 @@ func execSass(c *config.Config, q *registry.Queue, mode string) error {
 		} else if mode == "prod" {
 			args = append(args, "--style", "compressed")
 		}
-		output, err := utils.Exec("sass", args)
+		output, err := utils.Exec(ctx, "sass", args)
 		if err != nil {
-			fmt.Println(output)
-			return fmt.Errorf("compiler error: %s", err)
+			fmt.Fprintln(utils.Output(ctx), output)
+			utils.ReportProblem(ctx, parseError(output, file.Src))
+			return fmt.Errorf("compiler error: %w", err)
 		}
 
 		if err := utils.WriteFile(file.Dest, output); err != nil {
-			return fmt.Errorf("write file failed: %s", err)
+			return fmt.Errorf("write file failed: %w", err)
 		}
 
 		if *config.Verbose {
-			log.Printf("created file %s\n", file.Dest)
+			utils.Logf(ctx, "created file %s\n", file.Dest)
 		}
 	}
 	return nil
 }
 
 type sassFile struct {
-	Src, Dest string
+	Src  string `config:"source,required"`
+	Dest string `config:"dest,required"`
 }
 
 func sassFromConfig(c *config.Config, mode string) ([]*sassFile, error) {
+	from, err := stylesDir(c, mode)
+	if err != nil {
+		return nil, err
+	}
+
+	files := []*sassFile{}
+	if err := c.Decode(&files, "sass"); err != nil {
+		return nil, err
+	}
+	for _, file := range files {
+		file.Src = filepath.Join(from, file.Src)
+		file.Dest = filepath.Join("temp", "styles", file.Dest)
+	}
+	return files, nil
+}
+
+// stylesDir returns the folder of the sass sources: the styles of the app
+// (or of temp when building), or the root styles folder of the projects
+// that use the closure library.
+func stylesDir(c *config.Config, mode string) (string, error) {
+	library, err := c.GetDefault("closure.library", "")
+	if err != nil {
+		return "", err
+	}
+
 	var from string
-	if len(c.GetDefault("closure.library", "")) == 0 {
+	if len(library) == 0 {
 		if mode == "dev" {
 			from = filepath.Join("app")
 		} else if mode == "prod" {
 			from = filepath.Join("temp")
 		}
 	}
+	return filepath.Join(from, "styles"), nil
+}
 
-	files := []*sassFile{}
-	size := c.CountRequired("sass")
-	for i := 0; i < size; i++ {
-		src := filepath.Join(from, "styles", c.GetRequired("sass[%d].source", i))
-		dest := filepath.Join("temp", "styles", c.GetRequired("sass[%d].dest", i))
-		files = append(files, &sassFile{src, dest})
+// sassInputs returns the sources of the config and the partials they can
+// import from the same folder.
+func sassInputs(mode string) registry.InputsFunc {
+	return func(c *config.Config) ([]string, error) {
+		files, err := sassFromConfig(c, mode)
+		if err != nil {
+			return nil, err
+		}
+		from, err := stylesDir(c, mode)
+		if err != nil {
+			return nil, err
+		}
+
+		inputs := []string{filepath.Join(from, "**", "*.{scss,sass}")}
+		for _, file := range files {
+			inputs = append(inputs, file.Src)
+		}
+		return inputs, nil
 	}
-	return files, nil
 }
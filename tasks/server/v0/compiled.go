@@ -0,0 +1,110 @@
+package v0
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+
+	"github.com/ernestokarim/cb/config"
+	"github.com/ernestokarim/cb/registry"
+)
+
+// Names given by cacherev: the first 8 chars of the hash of the content.
+var revNameRe = regexp.MustCompile(`^[0-9a-f]{8}\.`)
+
+func init() {
+	registry.NewUserTask("server:angular:compiled", 0, serverCompiled).
+		Describe("build the app and serve the dist folder, like in production").
+		RequiresFirst("update:check@0").
+		Requires("dist:copy@0").
+		Reads(
+			"[serve.url=http://localhost:8080/]",
+			"[serve.base=proxy]",
+			"[serve.proxy:list]",
+			"<serve.proxy[].host>",
+			"<serve.proxy[].url>",
+			"<paths.base>",
+		)
+}
+
+// serverCompiled serves the files of the dist folder, falling back to the
+// proxy for the rest of the requests like the development server.
+func serverCompiled(c *config.Config, q *registry.Queue) error {
+	sc, err := readServeConfig(c)
+	if err != nil {
+		return err
+	}
+	if err := configureExts(); err != nil {
+		return fmt.Errorf("configure exts failed")
+	}
+	base, err := c.GetRequired("paths.base")
+	if err != nil {
+		return err
+	}
+
+	if *config.Verbose {
+		log.Printf("proxy url: %s (serve base: %+v)\n", sc.url, sc.base)
+		log.Printf("proxy mappings: %+v\n", sc.proxy)
+	}
+
+	http.Handle("/scenarios/", wrapHandler(c, q, scenariosHandler))
+	http.Handle("/test", wrapHandler(c, q, testHandler))
+	http.Handle("/utils.js", wrapHandler(c, q, scenariosHandler))
+	http.Handle("/angular-scenario.js", wrapHandler(c, q, angularScenarioHandler))
+
+	p, err := NewProxy(sc)
+	if err != nil {
+		return fmt.Errorf("cannot prepare proxy: %w", err)
+	}
+	http.Handle("/", newDistHandler(p, sc.base, filepath.Base(base)))
+
+	return listen(q, sc)
+}
+
+// distHandler serves the files present in the dist folder. The revisioned
+// ones can be cached forever, the name changes with the content.
+type distHandler struct {
+	proxy, files http.Handler
+
+	// Serve the index from dist instead of the proxy
+	base  bool
+	index string
+}
+
+func newDistHandler(proxy http.Handler, base bool, index string) *distHandler {
+	h := &distHandler{proxy: proxy, base: base, index: index}
+	h.files = LoggingHandler(http.HandlerFunc(h.serveDist))
+	return h
+}
+
+func (h *distHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	info, err := os.Stat(h.filename(r))
+	if err != nil || info.IsDir() {
+		h.proxy.ServeHTTP(w, r)
+		return
+	}
+	h.files.ServeHTTP(w, r)
+}
+
+func (h *distHandler) serveDist(w http.ResponseWriter, r *http.Request) {
+	filename := h.filename(r)
+	if revNameRe.MatchString(filepath.Base(filename)) {
+		w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+	} else {
+		w.Header().Set("Cache-Control", "no-cache")
+	}
+	serveFile(w, r, filename)
+}
+
+// filename returns the file of the dist folder requested.
+func (h *distHandler) filename(r *http.Request) string {
+	name := path.Clean("/" + r.URL.Path)
+	if name == "/" && h.base {
+		name = "/" + h.index
+	}
+	return filepath.Join("dist", filepath.FromSlash(name))
+}
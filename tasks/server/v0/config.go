@@ -8,9 +8,10 @@ import (
 )
 
 type serveConfig struct {
-	base  bool
-	url   string
-	proxy []proxyConfig
+	base       bool
+	url        string
+	proxy      []proxyConfig
+	liveReload bool
 }
 
 type proxyConfig struct {
@@ -18,22 +19,45 @@ type proxyConfig struct {
 }
 
 func readServeConfig(c *config.Config) (*serveConfig, error) {
+	url, err := c.GetDefault("serve.url", "http://localhost:8080/")
+	if err != nil {
+		return nil, err
+	}
 	sc := &serveConfig{
 		base: true,
-		url:  c.GetDefault("serve.url", "http://localhost:8080/"),
+		url:  url,
 	}
 
-	method := c.GetDefault("serve.base", "")
+	method, err := c.GetDefault("serve.base", "")
+	if err != nil {
+		return nil, err
+	}
 	if method != "" && method != "proxy" && method != "cb" {
 		return nil, fmt.Errorf("serve.base config must be 'proxy' (default) or 'cb'")
 	}
 	sc.base = (method == "cb")
 
-	size := c.CountDefault("serve.proxy")
+	sc.liveReload, err = c.GetBoolDefault("serve.livereload", true)
+	if err != nil {
+		return nil, err
+	}
+
+	size, err := c.CountDefault("serve.proxy")
+	if err != nil {
+		return nil, err
+	}
 	for i := 0; i < size; i++ {
+		host, err := c.GetRequired("serve.proxy[%d].host", i)
+		if err != nil {
+			return nil, err
+		}
+		url, err := c.GetRequired("serve.proxy[%d].url", i)
+		if err != nil {
+			return nil, err
+		}
 		pc := proxyConfig{
-			host: fmt.Sprintf("%s:%d", c.GetRequired("serve.proxy[%d].host", i), *config.Port),
-			url:  c.GetRequired("serve.proxy[%d].url", i),
+			host: fmt.Sprintf("%s:%d", host, *config.Port),
+			url:  url,
 		}
 		sc.proxy = append(sc.proxy, pc)
 	}
@@ -47,7 +71,7 @@ func configureExts() error {
 	}
 	for ext, t := range exts {
 		if err := mime.AddExtensionType(ext, t); err != nil {
-			return fmt.Errorf("add extension failed: %s", err)
+			return fmt.Errorf("add extension failed: %w", err)
 		}
 	}
 	return nil
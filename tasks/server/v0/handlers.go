@@ -2,13 +2,14 @@ package v0
 
 import (
 	"fmt"
+	"log"
 	"net/http"
 	"os"
 	"path/filepath"
-	"strings"
 	"sync"
 	"time"
 
+	"github.com/ernestokarim/cb/colors"
 	"github.com/ernestokarim/cb/config"
 	"github.com/ernestokarim/cb/registry"
 	"github.com/ernestokarim/cb/watcher"
@@ -78,24 +79,31 @@ func stylesHandler(req *reqInfo) error {
 	defer stylesMutex.Unlock()
 
 	name := req.r.URL.Path[8:]
-	dests := []string{"sass", "recess"}
-	for _, dest := range dests {
-		size := req.c.CountRequired(dest)
+	for _, dest := range styleTasks {
+		size, err := req.c.CountDefault("%s", dest)
+		if err != nil {
+			return err
+		}
 		for i := 0; i < size; i++ {
-			style := req.c.GetRequired("%s[%d].dest", dest, i)
+			style, err := req.c.GetRequired("%s[%d].dest", dest, i)
+			if err != nil {
+				return err
+			}
 			if style != name {
 				continue
 			}
 
-			if m, err := watcher.CheckModified(dest); err != nil {
-				return fmt.Errorf("cache check failed: %s", err)
-			} else if !m {
-				break
-			}
-
-			tasks := strings.Split(dest, " ")
-			if err := req.q.RunTasks(req.c, tasks); err != nil {
-				return fmt.Errorf("exec tasks failed: %s", err)
+			// The watcher could be debouncing the change right now
+			_, err = watcher.Refresh(dest, func(changes *watcher.Changes) error {
+				return live.runTask(req.c, req.q, dest)
+			})
+			if err != nil {
+				// Keep the page styled with the last version that compiled,
+				// the errors are shown over it
+				if _, statErr := os.Stat(filepath.Join("temp", req.r.URL.Path)); statErr != nil {
+					return fmt.Errorf("refresh styles failed: %w", err)
+				}
+				log.Printf("%srefresh styles failed: %s%s\n", colors.Red, err, colors.Reset)
 			}
 			break
 		}
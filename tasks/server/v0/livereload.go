@@ -0,0 +1,323 @@
+package v0
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ernestokarim/cb/config"
+	"github.com/ernestokarim/cb/registry"
+	"github.com/ernestokarim/cb/utils"
+)
+
+const (
+	liveReloadEvents = "/__cb/events"
+	liveReloadScript = "/__cb/livereload.js"
+
+	// Comments sent to keep the connections open through the proxies
+	keepAlive = 30 * time.Second
+)
+
+// Tasks that compile the stylesheets, the rest of them reload the page.
+var styleTasks = []string{"sass", "recess"}
+
+// Connection with the open pages, shared by the handlers.
+var live = newLiveReload()
+
+// reloadEvent is sent to the browsers when a watched task finishes.
+type reloadEvent struct {
+	// css to replace the stylesheets in Paths, reload for the whole page
+	// and problems to show the errors of the tasks over it
+	Type     string           `json:"type"`
+	Paths    []string         `json:"paths,omitempty"`
+	Problems []*utils.Problem `json:"problems,omitempty"`
+}
+
+// liveReload sends the changes of the app to the open pages, using
+// Server-Sent Events. The problems found by the tools are shown in an
+// overlay until the task that found them runs successfully again.
+type liveReload struct {
+	mutex   sync.Mutex
+	clients map[chan *reloadEvent]bool
+
+	// Send the changes of the files, not only the problems
+	enabled bool
+
+	// Problems of the last run of each task
+	problems map[string][]*utils.Problem
+}
+
+func newLiveReload() *liveReload {
+	return &liveReload{
+		clients:  map[chan *reloadEvent]bool{},
+		problems: map[string][]*utils.Problem{},
+	}
+}
+
+func (lr *liveReload) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming not supported", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	fmt.Fprint(w, "retry: 1000\n\n")
+	flusher.Flush()
+
+	events := make(chan *reloadEvent, 8)
+	lr.mutex.Lock()
+	lr.clients[events] = true
+	events <- lr.problemsEvent()
+	lr.mutex.Unlock()
+	defer func() {
+		lr.mutex.Lock()
+		delete(lr.clients, events)
+		lr.mutex.Unlock()
+	}()
+
+	ticker := time.NewTicker(keepAlive)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+
+		case <-ticker.C:
+			fmt.Fprint(w, ": keep-alive\n\n")
+
+		case e := <-events:
+			data, err := json.Marshal(e)
+			if err != nil {
+				return
+			}
+			fmt.Fprintf(w, "event: %s\ndata: %s\n\n", e.Type, data)
+		}
+		flusher.Flush()
+	}
+}
+
+// broadcast sends the event to all the open pages. Slow clients miss it
+// instead of blocking the rest of them.
+func (lr *liveReload) broadcast(e *reloadEvent) {
+	lr.mutex.Lock()
+	defer lr.mutex.Unlock()
+	lr.broadcastLocked(e)
+}
+
+func (lr *liveReload) broadcastLocked(e *reloadEvent) {
+	for client := range lr.clients {
+		select {
+		case client <- e:
+		default:
+		}
+	}
+}
+
+// runTask runs the task collecting the problems found by its tools. If it
+// fails without reporting any of them the error itself is shown.
+func (lr *liveReload) runTask(c *config.Config, q *registry.Queue, task string) error {
+	var mutex sync.Mutex
+	found := []*utils.Problem{}
+	ctx := utils.WithProblems(q.Context(), func(p *utils.Problem) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		found = append(found, p)
+	})
+
+	err := q.WithContext(ctx).RunTasks(c, []string{task})
+	if err == nil {
+		found = nil
+	} else if len(found) == 0 {
+		found = append(found, &utils.Problem{Tool: task, Message: err.Error()})
+	}
+	for _, p := range found {
+		if p.Message == "" {
+			p.Message = err.Error()
+		}
+	}
+
+	lr.mutex.Lock()
+	defer lr.mutex.Unlock()
+	if len(found) == 0 && len(lr.problems[task]) == 0 {
+		return err
+	}
+	if len(found) == 0 {
+		delete(lr.problems, task)
+	} else {
+		lr.problems[task] = found
+	}
+	lr.broadcastLocked(lr.problemsEvent())
+	return err
+}
+
+// problemsEvent returns the current problems of all the tasks. It should
+// be called with the mutex held.
+func (lr *liveReload) problemsEvent() *reloadEvent {
+	tasks := []string{}
+	for task := range lr.problems {
+		tasks = append(tasks, task)
+	}
+	sort.Strings(tasks)
+
+	e := &reloadEvent{Type: "problems", Problems: []*utils.Problem{}}
+	for _, task := range tasks {
+		e.Problems = append(e.Problems, lr.problems[task]...)
+	}
+	return e
+}
+
+// taskFinished notifies the pages after running a watched task: the
+// stylesheets are replaced in place, anything else reloads the page.
+func (lr *liveReload) taskFinished(c *config.Config, task string) {
+	if !lr.enabled {
+		return
+	}
+	for _, style := range styleTasks {
+		if task != style {
+			continue
+		}
+		e := &reloadEvent{Type: "css"}
+		for _, dest := range c.Values(style + "[].dest") {
+			e.Paths = append(e.Paths, path.Join("/styles", dest))
+		}
+		lr.broadcast(e)
+		return
+	}
+	lr.broadcast(&reloadEvent{Type: "reload"})
+}
+
+// uncompressed asks the proxied servers for plain responses, so the pages can
+// be modified by injectScript.
+func uncompressed(director func(*http.Request)) func(*http.Request) {
+	return func(r *http.Request) {
+		director(r)
+		r.Header.Del("Accept-Encoding")
+	}
+}
+
+// injectScript adds the live reload client at the end of the HTML pages.
+// Other responses, including the templates without a body, are untouched.
+func injectScript(resp *http.Response) error {
+	if !strings.HasPrefix(resp.Header.Get("Content-Type"), "text/html") ||
+		resp.Header.Get("Content-Encoding") != "" {
+		return nil
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("read response failed: %w", err)
+	}
+	resp.Body.Close()
+
+	body = addScript(body)
+	resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+	resp.ContentLength = int64(len(body))
+	resp.Header.Set("Content-Length", strconv.Itoa(len(body)))
+	return nil
+}
+
+func addScript(body []byte) []byte {
+	i := bytes.LastIndex(bytes.ToLower(body), []byte("</body>"))
+	if i == -1 {
+		return body
+	}
+	tag := fmt.Sprintf(`<script src="%s"></script>`, liveReloadScript)
+
+	result := make([]byte, 0, len(body)+len(tag))
+	result = append(result, body[:i]...)
+	result = append(result, tag...)
+	return append(result, body[i:]...)
+}
+
+func liveReloadHandler(req *reqInfo) error {
+	req.w.Header().Set("Content-Type", "application/javascript")
+	req.w.Header().Set("Cache-Control", "no-cache")
+	fmt.Fprintf(req.w, liveReloadClient, liveReloadEvents)
+	return nil
+}
+
+const liveReloadClient = `(function() {
+  if (!window.EventSource) {
+    return;
+  }
+
+  var source = new EventSource('%s');
+  source.addEventListener('problems', function(e) {
+    showProblems(JSON.parse(e.data).problems || []);
+  });
+  source.addEventListener('reload', function() {
+    window.location.reload();
+  });
+  source.addEventListener('css', function(e) {
+    var paths = JSON.parse(e.data).paths || [];
+    var links = document.querySelectorAll('link[rel="stylesheet"]');
+    for (var i = 0; i < links.length; i++) {
+      var href = links[i].getAttribute('href').split('?')[0];
+      for (var j = 0; j < paths.length; j++) {
+        if (href === paths[j] || href === paths[j].substr(1)) {
+          links[i].setAttribute('href', href + '?cb=' + Date.now());
+        }
+      }
+    }
+  });
+
+  function showProblems(problems) {
+    var overlay = document.getElementById('cb-overlay');
+    if (overlay) {
+      overlay.parentNode.removeChild(overlay);
+    }
+    if (!problems.length) {
+      return;
+    }
+
+    overlay = document.createElement('div');
+    overlay.id = 'cb-overlay';
+    overlay.style.cssText = 'position: fixed; top: 0; left: 0; right: 0; bottom: 0; ' +
+        'z-index: 2147483647; overflow: auto; padding: 24px; ' +
+        'background: rgba(20, 20, 20, 0.92); color: #eee; ' +
+        'font: 14px/1.5 Menlo, Consolas, monospace;';
+
+    var title = document.createElement('div');
+    title.style.cssText = 'color: #ff6b6b; font-size: 18px; margin-bottom: 16px;';
+    title.textContent = 'Build failed';
+    overlay.appendChild(title);
+
+    for (var i = 0; i < problems.length; i++) {
+      var p = problems[i];
+      var location = p.file || '';
+      if (p.line) {
+        location += ':' + p.line + (p.column ? ':' + p.column : '');
+      }
+
+      var item = document.createElement('pre');
+      item.style.cssText = 'margin: 0 0 16px; white-space: pre-wrap;';
+      var header = document.createElement('div');
+      header.style.cssText = 'color: #ffd479;';
+      header.textContent = '[' + p.tool + '] ' + location;
+      item.appendChild(header);
+      item.appendChild(document.createTextNode(p.message));
+      overlay.appendChild(item);
+    }
+
+    var close = document.createElement('div');
+    close.style.cssText = 'color: #999; cursor: pointer;';
+    close.textContent = 'Close (the errors come back with the next failed build)';
+    close.onclick = function() {
+      overlay.parentNode.removeChild(overlay);
+    };
+    overlay.appendChild(close);
+
+    document.body.appendChild(overlay);
+  }
+})();
+`
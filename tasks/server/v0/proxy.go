@@ -30,7 +30,7 @@ func (p *proxy) RoundTrip(r *http.Request) (*http.Response, error) {
 	// Make the real request
 	resp, err := http.DefaultTransport.RoundTrip(r)
 	if err != nil {
-		return nil, fmt.Errorf("roundtrip failed: %s", err)
+		return nil, fmt.Errorf("roundtrip failed: %w", err)
 	}
 
 	// Log the request data
@@ -39,7 +39,7 @@ func (p *proxy) RoundTrip(r *http.Request) (*http.Response, error) {
 	if length != "" {
 		size, err = strconv.ParseInt(length, 10, 64)
 		if err != nil {
-			return nil, fmt.Errorf("cannot parse resp size: %s", err)
+			return nil, fmt.Errorf("cannot parse resp size: %w", err)
 		}
 	}
 	var zero time.Time
@@ -49,7 +49,7 @@ func (p *proxy) RoundTrip(r *http.Request) (*http.Response, error) {
 	if resp.StatusCode == 302 || resp.StatusCode == 301 {
 		location, err := url.Parse(resp.Header.Get("Location"))
 		if err != nil {
-			return nil, fmt.Errorf("cannot parse the redirect url: %s", err)
+			return nil, fmt.Errorf("cannot parse the redirect url: %w", err)
 		}
 		location.Host = fmt.Sprintf("%s:%d", r.Host, *config.Port)
 		resp.Header.Set("Location", location.String())
@@ -63,7 +63,7 @@ func NewProxy(sc *serveConfig) (*httputil.ReverseProxy, error) {
 	if sc.proxy == nil {
 		proxyURL, err := url.Parse(sc.url)
 		if err != nil {
-			return nil, fmt.Errorf("parse proxied url failed: %s", err)
+			return nil, fmt.Errorf("parse proxied url failed: %w", err)
 		}
 		p := httputil.NewSingleHostReverseProxy(proxyURL)
 		p.Transport = &proxy{
@@ -79,7 +79,7 @@ func NewProxy(sc *serveConfig) (*httputil.ReverseProxy, error) {
 	for _, pc := range sc.proxy {
 		u, err := url.Parse(pc.url)
 		if err != nil {
-			return nil, fmt.Errorf("cannot parse url: %s", err)
+			return nil, fmt.Errorf("cannot parse url: %w", err)
 		}
 		p := httputil.NewSingleHostReverseProxy(u)
 		directors = append(directors, p.Director)
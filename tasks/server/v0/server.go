@@ -8,25 +8,37 @@ import (
 	"github.com/ernestokarim/cb/colors"
 	"github.com/ernestokarim/cb/config"
 	"github.com/ernestokarim/cb/registry"
+	"github.com/ernestokarim/cb/watcher"
 )
 
 func init() {
-	registry.NewUserTask("server", 0, server)
-	registry.NewUserTask("serve", 0, server)
-}
-
-func server(c *config.Config, q *registry.Queue) error {
-	tasks := []string{
-		"update:check@0",
+	deps := []string{
 		"clean@0",
 		"recess@0",
 		"sass@0",
 		"watch@0",
 	}
-	if err := q.RunTasks(c, tasks); err != nil {
-		return err
+	desc := "serve the app for development, watching the source files"
+	configs := []string{
+		"[serve.url=http://localhost:8080/]",
+		"[serve.base=proxy]",
+		"[serve.livereload:bool=true]",
+		"[serve.proxy:list]",
+		"<serve.proxy[].host>",
+		"<serve.proxy[].url>",
+		"<recess[].dest>",
+		"<sass[].dest>",
 	}
+	for _, name := range []string{"server", "serve"} {
+		registry.NewUserTask(name, 0, server).
+			Describe(desc).
+			RequiresFirst("update:check@0").
+			Requires(deps...).
+			Reads(configs...)
+	}
+}
 
+func server(c *config.Config, q *registry.Queue) error {
 	sc, err := readServeConfig(c)
 	if err != nil {
 		return err
@@ -51,17 +63,55 @@ func server(c *config.Config, q *registry.Queue) error {
 	http.Handle("/components/", wrapHandler(c, q, appHandler))
 	http.Handle("/views/", wrapHandler(c, q, appHandler))
 
+	live.enabled = sc.liveReload
+	http.Handle(liveReloadEvents, live)
+	http.Handle(liveReloadScript, wrapHandler(c, q, liveReloadHandler))
+
 	p, err := NewProxy(sc)
 	if err != nil {
-		return fmt.Errorf("cannot prepare proxy: %s", err)
+		return fmt.Errorf("cannot prepare proxy: %w", err)
 	}
+	p.Director = uncompressed(p.Director)
+	p.ModifyResponse = injectScript
 	http.Handle("/", p)
 
+	// Run the tasks of the watched files when they change
+	if !*config.DryRun {
+		go func() {
+			err := watcher.Watch(q.Context(), func(task string) error {
+				if err := live.runTask(c, q, task); err != nil {
+					return err
+				}
+				live.taskFinished(c, task)
+				return nil
+			})
+			if err != nil {
+				log.Printf("%swatcher failed: %s%s\n", colors.Red, err, colors.Reset)
+			}
+		}()
+	}
+
+	return listen(q, sc)
+}
+
+// listen serves the handlers registered until cb is interrupted.
+func listen(q *registry.Queue, sc *serveConfig) error {
 	for _, proxyURL := range sc.proxy {
 		log.Printf("%sserving app at http://%s/...%s\n", colors.Yellow, proxyURL.host, colors.Reset)
 	}
-	if err := http.ListenAndServe(fmt.Sprintf(":%d", *config.Port), nil); err != nil {
-		return fmt.Errorf("server listener failed: %s", err)
+	if *config.DryRun {
+		return nil
+	}
+
+	// Stop the server when cb is interrupted
+	srv := &http.Server{Addr: fmt.Sprintf(":%d", *config.Port)}
+	go func() {
+		<-q.Context().Done()
+		srv.Close()
+	}()
+
+	if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		return fmt.Errorf("server listener failed: %w", err)
 	}
 	return nil
 }
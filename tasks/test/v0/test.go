@@ -10,11 +10,16 @@ import (
 )
 
 func init() {
-	registry.NewUserTask("test", 0, test)
-	registry.NewUserTask("test:subl", 0, testSubl)
-	registry.NewUserTask("test:*", 0, testGreedy)
-	registry.NewUserTask("e2e", 0, e2e)
-	registry.NewUserTask("e2e:compiled", 0, e2eCompiled)
+	registry.NewUserTask("test", 0, test).
+		Describe("run the unit tests with karma")
+	registry.NewUserTask("test:subl", 0, testSubl).
+		Describe("run the unit tests once, with an output for editors")
+	registry.NewUserTask("test:*", 0, testGreedy).
+		Describe("run the tests with the karma config named after the colon")
+	registry.NewUserTask("e2e", 0, e2e).
+		Describe("serve the app for the e2e tests")
+	registry.NewUserTask("e2e:compiled", 0, e2eCompiled).
+		Describe("serve the compiled app for the e2e tests")
 }
 
 func test(c *config.Config, q *registry.Queue) error {
@@ -24,8 +29,8 @@ func test(c *config.Config, q *registry.Queue) error {
 	}
 	args = append(args, "config/karma.conf.js")
 
-	if err := utils.ExecCopyOutput("karma", args); err != nil {
-		return fmt.Errorf("exec failed: %s", err)
+	if err := utils.ExecCopyOutput(q.Context(), "karma", args); err != nil {
+		return fmt.Errorf("exec failed: %w", err)
 	}
 	return nil
 }
@@ -38,8 +43,8 @@ func testSubl(c *config.Config, q *registry.Queue) error {
 		"--single-run",
 	}
 
-	if err := utils.ExecCopyOutput("karma", args); err != nil {
-		return fmt.Errorf("exec failed: %s", err)
+	if err := utils.ExecCopyOutput(q.Context(), "karma", args); err != nil {
+		return fmt.Errorf("exec failed: %w", err)
 	}
 	return nil
 }
@@ -52,8 +57,8 @@ func testGreedy(c *config.Config, q *registry.Queue) error {
 	parts := strings.Split(q.CurTask, ":")
 	args = append(args, fmt.Sprintf("config/karma-%s.conf.js", parts[1]))
 
-	if err := utils.ExecCopyOutput("karma", args); err != nil {
-		return fmt.Errorf("exec failed: %s", err)
+	if err := utils.ExecCopyOutput(q.Context(), "karma", args); err != nil {
+		return fmt.Errorf("exec failed: %w", err)
 	}
 	return nil
 }
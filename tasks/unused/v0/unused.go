@@ -16,13 +16,14 @@ var (
 )
 
 func init() {
-	registry.NewUserTask("unused", 0, unused)
+	registry.NewUserTask("unused", 0, unused).
+		Describe("find unused variables in the scripts")
 }
 
 func unused(c *config.Config, q *registry.Queue) error {
 	walkFn := func(path string, info os.FileInfo, err error) error {
 		if err != nil {
-			return fmt.Errorf("recursive walk error: %s", err)
+			return fmt.Errorf("recursive walk error: %w", err)
 		}
 		if info.IsDir() {
 			for _, exclude := range excludes {
@@ -34,9 +35,9 @@ func unused(c *config.Config, q *registry.Queue) error {
 		}
 		if filepath.Ext(path) == ".js" {
 			args := []string{path, "--ignore-params", "_,_2,_3,_4,_5"}
-			output, err := utils.Exec("unused", args)
+			output, err := utils.Exec(q.Context(), "unused", args)
 			if err != nil {
-				fmt.Println(output)
+				fmt.Fprintln(utils.Output(q.Context()), output)
 			}
 		}
 		return nil
@@ -44,7 +45,7 @@ func unused(c *config.Config, q *registry.Queue) error {
 
 	for _, folder := range folders {
 		if err := filepath.Walk(folder, walkFn); err != nil {
-			return fmt.Errorf("walk error: %s", err)
+			return fmt.Errorf("walk error: %w", err)
 		}
 	}
 	return nil
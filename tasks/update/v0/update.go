@@ -1,9 +1,9 @@
 package v0
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
-	"log"
 	"net/http"
 	"net/url"
 	"os"
@@ -20,17 +20,20 @@ import (
 const updateURL = `https://api.github.com/repos/ernestokarim/cb/commits?per_page=1`
 
 func init() {
-	registry.NewUserTask("update", 0, update)
-	registry.NewTask("update:check", 0, updateCheck)
+	registry.NewUserTask("update", 0, update).
+		Describe("update cb to the latest version")
+	registry.NewTask("update:check", 0, updateCheck).
+		Describe("warn once a day if there is a new version of cb")
 }
 
 func update(c *config.Config, q *registry.Queue) error {
+	ctx := q.Context()
 	// Fetch last commits, both localy & remotely
-	latestSha, err := fetchLatestCommit()
+	latestSha, err := fetchLatestCommit(ctx)
 	if err != nil {
 		return err
 	}
-	currentSha, err := fetchCurrentCommit()
+	currentSha, err := fetchCurrentCommit(ctx)
 	if err != nil {
 		return err
 	}
@@ -38,38 +41,38 @@ func update(c *config.Config, q *registry.Queue) error {
 	// Couldn't retrieve current/latest commit, ignore update
 	if latestSha == "" || currentSha == "" {
 		if *config.Verbose {
-			log.Printf("local or remote version was not retrieved correctly\n")
+			utils.Logf(ctx, "local or remote version was not retrieved correctly\n")
 		}
 		return nil
 	}
 
-	if err := writeCheckUpdate(); err != nil {
+	if err := writeCheckUpdate(ctx); err != nil {
 		return err
 	}
 
 	// No update, return directly
 	if latestSha == currentSha {
 		if *config.Verbose {
-			log.Printf("same version detected\n")
+			utils.Logf(ctx, "same version detected\n")
 		}
 		return nil
 	}
 
 	// Perform the update
 	args := []string{"get", "-u", "github.com/ernestokarim/cb"}
-	output, err := utils.Exec("go", args)
+	output, err := utils.Exec(ctx, "go", args)
 	if err != nil {
 		return err
 	}
 	if len(output) > 0 {
-		fmt.Println(output)
+		fmt.Fprintln(utils.Output(ctx), output)
 	}
 
-	log.Printf("%sUpdated correctly to commit: %s%s", colors.Green, latestSha[:10], colors.Reset)
+	utils.Logf(ctx, "%sUpdated correctly to commit: %s%s\n", colors.Green, latestSha[:10], colors.Reset)
 
 	// Rerun itself with the correct args
-	if err := utils.ExecCopyOutput(os.Args[0], os.Args[1:]); err != nil {
-		return fmt.Errorf("error re-executing itself with the same arguments: %s", err)
+	if err := utils.ExecCopyOutput(ctx, os.Args[0], os.Args[1:]); err != nil {
+		return fmt.Errorf("error re-executing itself with the same arguments: %w", err)
 	}
 	os.Exit(1)
 
@@ -81,8 +84,9 @@ type commitInfo struct {
 }
 
 func updateCheck(c *config.Config, q *registry.Queue) error {
+	ctx := q.Context()
 	// Check update-check file before updating again
-	shouldCheck, err := checkShouldCheckUpdate()
+	shouldCheck, err := checkShouldCheckUpdate(ctx)
 	if err != nil {
 		return err
 	}
@@ -91,11 +95,11 @@ func updateCheck(c *config.Config, q *registry.Queue) error {
 	}
 
 	// Fetch last commits, both localy & remotely
-	latestSha, err := fetchLatestCommit()
+	latestSha, err := fetchLatestCommit(ctx)
 	if err != nil {
 		return err
 	}
-	currentSha, err := fetchCurrentCommit()
+	currentSha, err := fetchCurrentCommit(ctx)
 	if err != nil {
 		return err
 	}
@@ -103,19 +107,19 @@ func updateCheck(c *config.Config, q *registry.Queue) error {
 	// Couldn't retrieve current/latest commit, ignore update
 	if latestSha == "" || currentSha == "" {
 		if *config.Verbose {
-			log.Printf("local or remote version was not retrieved correctly\n")
+			utils.Logf(ctx, "local or remote version was not retrieved correctly\n")
 		}
 		return nil
 	}
 
-	if err := writeCheckUpdate(); err != nil {
+	if err := writeCheckUpdate(ctx); err != nil {
 		return err
 	}
 
 	// No update, return directly
 	if latestSha == currentSha {
 		if *config.Verbose {
-			log.Printf("same version detected\n")
+			utils.Logf(ctx, "same version detected\n")
 		}
 		return nil
 	}
@@ -128,14 +132,13 @@ func updateCheck(c *config.Config, q *registry.Queue) error {
 	return nil
 }
 
-func fetchLatestCommit() (string, error) {
+func fetchLatestCommit(ctx context.Context) (string, error) {
 	resp, err := http.Get(updateURL)
 	if err != nil {
 		// If there's no Internet connection, don't return an error
 		if e, ok := err.(*url.Error); ok {
 			if e.Err.Error() == "dial tcp: lookup api.github.com: no such host" {
-				log.Printf("%scannot check for updates, there's no connection%s\n",
-					colors.Yellow, colors.Reset)
+				utils.Warningf(ctx, "cannot check for updates, there's no connection")
 				return "", nil
 			}
 		}
@@ -148,13 +151,13 @@ func fetchLatestCommit() (string, error) {
 	// Extract the commit info
 	var data []*commitInfo
 	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
-		return "", fmt.Errorf("cannot decode github api data: %s", err)
+		return "", fmt.Errorf("cannot decode github api data: %w", err)
 	}
 
 	return data[0].Sha, nil
 }
 
-func fetchCurrentCommit() (string, error) {
+func fetchCurrentCommit(ctx context.Context) (string, error) {
 	path := utils.PackagePath("github.com/ernestokarim/cb")
 
 	args := []string{
@@ -163,24 +166,24 @@ func fetchCurrentCommit() (string, error) {
 		"rev-parse",
 		"HEAD",
 	}
-	output, err := utils.Exec("git", args)
+	output, err := utils.Exec(ctx, "git", args)
 	if err != nil {
-		return "", fmt.Errorf("cannot parse git head revision: %s", err)
+		return "", fmt.Errorf("cannot parse git head revision: %w", err)
 	}
 
 	return strings.TrimSpace(output), nil
 }
 
-func checkShouldCheckUpdate() (bool, error) {
+func checkShouldCheckUpdate(ctx context.Context) (bool, error) {
 	p := config.GetUserConfigsPath()
 	info, err := os.Stat(filepath.Join(p, "update-check"))
 	if err != nil && !os.IsNotExist(err) {
-		return false, fmt.Errorf("cannot stat update check file: %s", err)
+		return false, fmt.Errorf("cannot stat update check file: %w", err)
 	}
 
 	if err == nil && time.Now().Sub(info.ModTime()) < 24*time.Hour {
 		if *config.Verbose {
-			log.Printf("ignoring update because it has been checked in the last 24 hours\n")
+			utils.Logf(ctx, "ignoring update because it has been checked in the last 24 hours\n")
 		}
 		return false, nil
 	}
@@ -188,16 +191,16 @@ func checkShouldCheckUpdate() (bool, error) {
 	return true, nil
 }
 
-func writeCheckUpdate() error {
+func writeCheckUpdate(ctx context.Context) error {
 	p := config.GetUserConfigsPath()
-	f, err := os.Create(filepath.Join(p, "update-check"))
+	f, err := utils.CreateFile(filepath.Join(p, "update-check"))
 	if err != nil {
-		return fmt.Errorf("cannot create update check file: %s", err)
+		return fmt.Errorf("cannot create update check file: %w", err)
 	}
 	defer f.Close()
 
 	if *config.Verbose {
-		log.Printf("writing update-check file\n")
+		utils.Logf(ctx, "writing update-check file\n")
 	}
 
 	return nil
@@ -15,11 +15,11 @@ func generateObject(e *emitter, varname, result string, fields []*field) error {
 		}
 
 		if err := generateField(e, f, varname, result); err != nil {
-			return fmt.Errorf("generate field failed: %s", err)
+			return fmt.Errorf("generate field failed: %w", err)
 		}
 		if f.Kind != "Conditional" && f.Kind != "Array" {
 			if err := generateValidations(e, f); err != nil {
-				return fmt.Errorf("generate validators failed: %s", err)
+				return fmt.Errorf("generate validators failed: %w", err)
 			}
 
 			if f.Kind != "Object" {
@@ -44,11 +44,11 @@ func generateArray(e *emitter, varname, result string, fields []*field) error {
 		f.Key = fmt.Sprintf("$i%d", id)
 
 		if err := generateField(e, f, varname, result); err != nil {
-			return fmt.Errorf("generate field failed: %s", err)
+			return fmt.Errorf("generate field failed: %w", err)
 		}
 		if f.Kind != "Conditional" && f.Kind != "Array" {
 			if err := generateValidations(e, f); err != nil {
-				return fmt.Errorf("generate validators failed: %s", err)
+				return fmt.Errorf("generate validators failed: %w", err)
 			}
 
 			if f.Kind != "Object" {
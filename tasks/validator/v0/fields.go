@@ -21,7 +21,7 @@ func generateField(e *emitter, f *field, varname, result string) error {
 		return fmt.Errorf("`%s` is not a field kind", f.Kind)
 	}
 	if err := fields[f.Kind](e, f, varname, result); err != nil {
-		return fmt.Errorf("field generator failed: %s", err)
+		return fmt.Errorf("field generator failed: %w", err)
 	}
 
 	if f.Store != "" {
@@ -89,7 +89,7 @@ func objectField(e *emitter, f *field, varname, result string) error {
 	name := fmt.Sprintf("%s[%s]", varname, f.Key)
 	res := fmt.Sprintf("%s[%s]", result, f.Key)
 	if err := generateObject(e, name, res, f.Fields); err != nil {
-		return fmt.Errorf("generate object failed: %s", err)
+		return fmt.Errorf("generate object failed: %w", err)
 	}
 
 	return nil
@@ -107,13 +107,13 @@ func arrayField(e *emitter, f *field, varname, result string) error {
 	e.emitf("")
 
 	if err := generateValidations(e, f); err != nil {
-		return fmt.Errorf("generate validators failed: %s", err)
+		return fmt.Errorf("generate validators failed: %w", err)
 	}
 
 	name := fmt.Sprintf("%s[%s]", varname, f.Key)
 	res := fmt.Sprintf("%s[%s]", result, f.Key)
 	if err := generateArray(e, name, res, f.Fields); err != nil {
-		return fmt.Errorf("generate array failed: %s", err)
+		return fmt.Errorf("generate array failed: %w", err)
 	}
 
 	return nil
@@ -128,7 +128,7 @@ func conditionalField(e *emitter, f *field, varname, result string) error {
 	e.indent()
 
 	if err := generateObject(e, varname, result, f.Fields); err != nil {
-		return fmt.Errorf("generate object failed: %s", err)
+		return fmt.Errorf("generate object failed: %w", err)
 	}
 
 	e.unindent()
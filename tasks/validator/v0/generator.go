@@ -3,9 +3,10 @@ package v0
 import (
 	"bytes"
 	"fmt"
-	"os"
 	"path/filepath"
 	"strings"
+
+	"github.com/ernestokarim/cb/utils"
 )
 
 func generator(original, root string, fields []*field) error {
@@ -15,13 +16,9 @@ func generator(original, root string, fields []*field) error {
 	name := strings.Replace(strings.Title(filename), "-", "", -1)
 	destPath := filepath.Join("..", "app", "lib", "Validators", filepath.Dir(original), name+".php")
 
-	if err := os.MkdirAll(filepath.Dir(destPath), 0755); err != nil {
-		return fmt.Errorf("cannot create dest path: %s", err)
-	}
-
-	f, err := os.Create(destPath)
+	f, err := utils.CreateFile(destPath)
 	if err != nil {
-		return fmt.Errorf("cannot create dest file: %s", err)
+		return fmt.Errorf("cannot create dest file: %w", err)
 	}
 	defer f.Close()
 
@@ -30,11 +27,11 @@ func generator(original, root string, fields []*field) error {
 
 	if root == "Object" {
 		if err := generateObject(e, "data", "valid", fields); err != nil {
-			return fmt.Errorf("generate object fields failed: %s", err)
+			return fmt.Errorf("generate object fields failed: %w", err)
 		}
 	} else if root == "Array" {
 		if err := generateArray(e, "data", "valid", fields); err != nil {
-			return fmt.Errorf("generate array fields failed: %s", err)
+			return fmt.Errorf("generate array fields failed: %w", err)
 		}
 	}
 
@@ -7,52 +7,45 @@ import (
 )
 
 type field struct {
-	Key, Kind, Store, Condition string
-	Validators                  []*validator
-	Fields                      []*field
+	Key        string
+	Kind       string `config:"kind,required"`
+	Store      string
+	Condition  string
+	Validators []*validator
+	Fields     []*field
 }
 
 type validator struct {
-	Name, Value string
-	Uses        []string
+	Name  string `config:"name,required"`
+	Value string
+	Uses  []string `config:"use"`
 }
 
-func parseFields(data *config.Config, spec string) []*field {
+func parseFields(data *config.Config, spec string) ([]*field, error) {
 	fields := []*field{}
+	if err := data.Decode(&fields, "%s", spec); err != nil {
+		return nil, err
+	}
+	if err := checkFields(fields, spec); err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
 
-	size := data.CountRequired("%s", spec)
-	for i := 0; i < size; i++ {
-		field := &field{
-			Key:        data.GetDefault("%s[%d].key", "", spec, i),
-			Kind:       data.GetRequired("%s[%d].kind", spec, i),
-			Store:      data.GetDefault("%s[%d].store", "", spec, i),
-			Condition:  data.GetDefault("%s[%d].condition", "", spec, i),
-			Validators: make([]*validator, 0),
-		}
-
+// checkFields verifies the container kinds have their own list of fields.
+func checkFields(fields []*field, spec string) error {
+	for i, field := range fields {
 		if field.Kind == "Array" || field.Kind == "Object" || field.Kind == "Conditional" {
 			newSpec := fmt.Sprintf("%s[%d].fields", spec, i)
-			field.Fields = parseFields(data, newSpec)
-		}
-
-		validatorsSize := data.CountDefault("%s[%d].validators", spec, i)
-		for j := 0; j < validatorsSize; j++ {
-			v := &validator{
-				Name:  data.GetRequired("%s[%d].validators[%d].name", spec, i, j),
-				Value: data.GetDefault("%s[%d].validators[%d].value", "", spec, i, j),
+			if field.Fields == nil {
+				return fmt.Errorf("required config element: %s", newSpec)
 			}
-
-			usesSize := data.CountDefault("%s[%d].validators[%d].use", spec, i, j)
-			for k := 0; k < usesSize; k++ {
-				value := data.GetDefault("%s[%d].validators[%d].use[%d]", "", spec, i, j, k)
-				v.Uses = append(v.Uses, value)
+			if err := checkFields(field.Fields, newSpec); err != nil {
+				return err
 			}
-
-			field.Validators = append(field.Validators, v)
+		} else {
+			field.Fields = nil
 		}
-
-		fields = append(fields, field)
 	}
-
-	return fields
+	return nil
 }
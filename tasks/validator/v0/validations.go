@@ -38,7 +38,7 @@ func generateValidations(e *emitter, f *field) error {
 			return fmt.Errorf("`%s` is not a validation", v.Name)
 		}
 		if err := validations[v.Name](e, f, v); err != nil {
-			return fmt.Errorf("validation generator failed: %s", err)
+			return fmt.Errorf("validation generator failed: %w", err)
 		}
 	}
 	return nil
@@ -95,7 +95,7 @@ func inArrayValidation(e *emitter, f *field, v *validator) error {
 func lengthValidation(e *emitter, f *field, v *validator) error {
 	val, err := strconv.ParseInt(v.Value, 10, 64)
 	if err != nil {
-		return fmt.Errorf("cannot parse length number: %s", err)
+		return fmt.Errorf("cannot parse length number: %w", err)
 	}
 
 	e.emitf(`if (Str::length($value) != %d) {`, val)
@@ -118,7 +118,7 @@ func matchValidation(e *emitter, f *field, v *validator) error {
 func maxLengthValidation(e *emitter, f *field, v *validator) error {
 	val, err := strconv.ParseInt(v.Value, 10, 64)
 	if err != nil {
-		return fmt.Errorf("cannot parse maxlength number: %s", err)
+		return fmt.Errorf("cannot parse maxlength number: %w", err)
 	}
 
 	e.emitf(`if (Str::length($value) > %d) {`, val)
@@ -130,7 +130,7 @@ func maxLengthValidation(e *emitter, f *field, v *validator) error {
 func minCountValidation(e *emitter, f *field, v *validator) error {
 	val, err := strconv.ParseInt(v.Value, 10, 64)
 	if err != nil {
-		return fmt.Errorf("cannot parse mincount number: %s", err)
+		return fmt.Errorf("cannot parse mincount number: %w", err)
 	}
 
 	e.emitf(`if (count($value) < %d) {`, val)
@@ -155,7 +155,7 @@ func minDateValidation(e *emitter, f *field, v *validator) error {
 func minLengthValidation(e *emitter, f *field, v *validator) error {
 	val, err := strconv.ParseInt(v.Value, 10, 64)
 	if err != nil {
-		return fmt.Errorf("cannot parse minlength number: %s", err)
+		return fmt.Errorf("cannot parse minlength number: %w", err)
 	}
 
 	e.emitf(`if (Str::length($value) < %d) {`, val)
@@ -167,7 +167,7 @@ func minLengthValidation(e *emitter, f *field, v *validator) error {
 func minLengthOptionalValidation(e *emitter, f *field, v *validator) error {
 	val, err := strconv.ParseInt(v.Value, 10, 64)
 	if err != nil {
-		return fmt.Errorf("cannot parse minlength number: %s", err)
+		return fmt.Errorf("cannot parse minlength number: %w", err)
 	}
 
 	e.emitf(`if ($value !== '' && Str::length($value) < %d) {`, val)
@@ -179,7 +179,7 @@ func minLengthOptionalValidation(e *emitter, f *field, v *validator) error {
 func minValueValidation(e *emitter, f *field, v *validator) error {
 	val, err := strconv.ParseInt(v.Value, 10, 64)
 	if err != nil {
-		return fmt.Errorf("cannot parse minvalue number: %s", err)
+		return fmt.Errorf("cannot parse minvalue number: %w", err)
 	}
 
 	e.emitf(`if ($value < %d) {`, val)
@@ -12,14 +12,15 @@ import (
 )
 
 func init() {
-	registry.NewUserTask("validator", 0, validatorTask)
+	registry.NewUserTask("validator", 0, validatorTask).
+		Describe("generate the PHP validators of the app/validators folder")
 }
 
 func validatorTask(c *config.Config, q *registry.Queue) error {
-	output, err := utils.Exec("rm", []string{"-rf", "../app/lib/Validators"})
+	output, err := utils.Exec(q.Context(), "rm", []string{"-rf", "../app/lib/Validators"})
 	if err != nil {
-		fmt.Println(output)
-		return fmt.Errorf("cannot remove original validators: %s", err)
+		fmt.Fprintln(utils.Output(q.Context()), output)
+		return fmt.Errorf("cannot remove original validators: %w", err)
 	}
 
 	rootPath := "../app/validators"
@@ -34,32 +35,38 @@ func validatorTask(c *config.Config, q *registry.Queue) error {
 		// Relative path
 		rel, err := filepath.Rel(rootPath, path)
 		if err != nil {
-			return fmt.Errorf("cannot rel validator path: %s", err)
+			return fmt.Errorf("cannot rel validator path: %w", err)
 		}
 
 		// Read file
 		f, err := yaml.ReadFile(path)
 		if err != nil {
-			return fmt.Errorf("read validator failed: %s", err)
+			return fmt.Errorf("read validator failed: %w", err)
 		}
 		data := config.NewConfig(f)
 
 		// Extract fields
-		root := data.GetDefault("root", "Object")
+		root, err := data.GetDefault("root", "Object")
+		if err != nil {
+			return fmt.Errorf("read validator failed (%s): %w", rel, err)
+		}
 		if root != "Object" && root != "Array" {
 			return fmt.Errorf("invalid root type, only 'object' and 'array' are accepted")
 		}
-		fields := parseFields(data, "fields")
+		fields, err := parseFields(data, "fields")
+		if err != nil {
+			return fmt.Errorf("read validator failed (%s): %w", rel, err)
+		}
 
 		// Generate validator
 		if err := generator(rel, root, fields); err != nil {
-			return fmt.Errorf("generator error: %s", err)
+			return fmt.Errorf("generator error: %w", err)
 		}
 
 		return nil
 	}
 	if err := filepath.Walk(rootPath, walkFn); err != nil {
-		return fmt.Errorf("walk validators failed: %s", err)
+		return fmt.Errorf("walk validators failed: %w", err)
 	}
 
 	return nil
@@ -9,27 +9,44 @@ import (
 )
 
 func init() {
-	registry.NewTask("watch", 0, watch)
+	registry.NewTask("watch", 0, watch).
+		Describe("register the folders that run tasks when they change").
+		Reads("<watch:list>", "<watch[].task>", "[watch[].paths:list]", "[watch[].ignore:list]")
+	registry.NewUserTask("watch:run", 0, watchRun).
+		Describe("run the tasks of the watched folders each time they change").
+		Requires("watch@0")
 }
 
 func watch(c *config.Config, q *registry.Queue) error {
-	size := c.CountRequired("watch")
-	for i := 0; i < size; i++ {
-		// Extract the task name
-		task := c.GetRequired("watch[%d].task", i)
-
-		// Extract the paths
-		paths := []string{}
-		pathsSize := c.CountDefault("watch[%d].paths", i)
-		for j := 0; j < pathsSize; j++ {
-			paths = append(paths, c.GetRequired("watch[%d].paths[%d]", i, j))
+	var entries []struct {
+		Task   string `config:"task,required"`
+		Paths  []string
+		Ignore []string
+	}
+	if err := c.Decode(&entries, "watch"); err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if !registry.IsTask(entry.Task) {
+			return fmt.Errorf("unknown task in watch config: %s", entry.Task)
 		}
 
 		// Init the watcher
-		if err := watcher.Dirs(paths, task); err != nil {
-			return fmt.Errorf("watch dirs failed: %s", err)
+		if err := watcher.Ignore(entry.Ignore, entry.Task); err != nil {
+			return fmt.Errorf("watch ignore failed: %w", err)
+		}
+		if err := watcher.Dirs(entry.Paths, entry.Task); err != nil {
+			return fmt.Errorf("watch dirs failed: %w", err)
 		}
-
 	}
 	return nil
 }
+
+func watchRun(c *config.Config, q *registry.Queue) error {
+	if *config.DryRun {
+		return nil
+	}
+	return watcher.Watch(q.Context(), func(task string) error {
+		return q.RunTasks(c, []string{task})
+	})
+}
@@ -0,0 +1,35 @@
+package utils
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/ernestokarim/cb/colors"
+	"github.com/ernestokarim/cb/config"
+)
+
+// dryRun reports an operation that would change something outside cb.
+// It returns true if we're in dry-run mode and the caller should skip
+// the operation.
+func dryRun(format string, a ...interface{}) bool {
+	if !*config.DryRun {
+		return false
+	}
+	log.Printf("%sDRY-RUN%s %s\n", colors.Magenta, colors.Reset,
+		fmt.Sprintf(format, a...))
+	return true
+}
+
+// commandLine formats a command the same way it would be typed in a shell.
+func commandLine(app string, args []string) string {
+	line := []string{app}
+	for _, arg := range args {
+		if arg == "" || strings.ContainsAny(arg, " \t\n\"'") {
+			arg = strconv.Quote(arg)
+		}
+		line = append(line, arg)
+	}
+	return strings.Join(line, " ")
+}
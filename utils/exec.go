@@ -1,11 +1,13 @@
 package utils
 
 import (
+	"bytes"
+	"context"
 	"fmt"
 	"io"
-	"log"
 	"os"
 	"os/exec"
+	"time"
 
 	"github.com/ernestokarim/cb/colors"
 	"github.com/ernestokarim/cb/config"
@@ -13,53 +15,104 @@ import (
 
 // Exec runs a new command and return the output and an error if present.
 // It's probably the core of cb as we use external tools for almost anything we do.
-func Exec(app string, args []string) (string, error) {
+// The command and its children are stopped if ctx is cancelled before
+// it finishes.
+func Exec(ctx context.Context, app string, args []string) (string, error) {
+	if dryRun("exec %s", commandLine(app, args)) {
+		return "", nil
+	}
 	if *config.Verbose {
-		log.Printf("%sEXEC%s %s %+v\n", colors.Yellow, colors.Reset,
+		Logf(ctx, "%sEXEC%s %s %+v\n", colors.Yellow, colors.Reset,
 			app, args)
 	}
 
+	start := time.Now()
+	var output bytes.Buffer
 	cmd := exec.Command(app, args...)
-	output, err := cmd.CombinedOutput()
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+	wait, err := startProcess(ctx, cmd)
+	if err != nil {
+		return "", fmt.Errorf("exec failed: %w", err)
+	}
+	err = wait()
+	trace(ctx, commandLine(app, args), start, err)
 	if err != nil {
-		err = fmt.Errorf("exec failed: %s", err)
+		return output.String(), fmt.Errorf("exec failed: %w", err)
 	}
-	return string(output), err
+	return output.String(), nil
 }
 
 // ExecCopyOutput runs a new command and keeps copying the output to stdout
 // until it finish. It's used in commands like `cb test` where we need to run
 // a permanent app and see the output right as it is produced.
-func ExecCopyOutput(app string, args []string) error {
-	if *config.Verbose {
-		log.Printf("%sEXEC %s %s %+v\n", colors.Yellow, colors.Reset,
-			app, args)
+func ExecCopyOutput(ctx context.Context, app string, args []string) error {
+	return ExecCommand(ctx, exec.Command(app, args...))
+}
+
+// ExecCommand works like ExecCopyOutput, but with a command prepared by the
+// caller (e.g. to change its working directory or environment). The command
+// and its children are stopped if ctx is cancelled before it finishes.
+func ExecCommand(ctx context.Context, cmd *exec.Cmd) error {
+	line := cmdLine(cmd)
+	if dryRun("exec %s", line) {
+		return nil
 	}
+	return runCommand(ctx, cmd, line)
+}
 
-	cmd := exec.Command(app, args...)
+// ExecDryRunCommand works like ExecCommand, but it runs the command in
+// dry-run mode too. It's used for the tools that get the mode and avoid
+// their own changes (e.g. the plugins receive CB_DRY_RUN=1).
+func ExecDryRunCommand(ctx context.Context, cmd *exec.Cmd) error {
+	return runCommand(ctx, cmd, cmdLine(cmd))
+}
 
+// cmdLine formats a prepared command, with its folder if it has one.
+func cmdLine(cmd *exec.Cmd) string {
+	line := commandLine(cmd.Args[0], cmd.Args[1:])
+	if cmd.Dir != "" {
+		line = fmt.Sprintf("%s (in %s)", line, cmd.Dir)
+	}
+	return line
+}
+
+func runCommand(ctx context.Context, cmd *exec.Cmd, line string) error {
+	if *config.Verbose {
+		Logf(ctx, "%sEXEC %s %s %+v\n", colors.Yellow, colors.Reset,
+			cmd.Args[0], cmd.Args[1:])
+	}
+
+	start := time.Now()
 	stdout, err := cmd.StdoutPipe()
 	if err != nil {
-		return fmt.Errorf("cannot create stdout pipe: %s", err)
+		return fmt.Errorf("cannot create stdout pipe: %w", err)
 	}
 	stderr, err := cmd.StderrPipe()
 	if err != nil {
-		return fmt.Errorf("cannot create stderr pipe: %s", err)
+		return fmt.Errorf("cannot create stderr pipe: %w", err)
 	}
 
-	if err := cmd.Start(); err != nil {
-		return fmt.Errorf("cannot run the command: %s", err)
+	wait, err := startProcess(ctx, cmd)
+	if err != nil {
+		return fmt.Errorf("cannot run the command: %w", err)
+	}
+
+	// Both streams go to the task output if it has been redirected
+	outw, errw := io.Writer(os.Stdout), io.Writer(os.Stderr)
+	if w := Output(ctx); w != os.Stdout {
+		outw, errw = w, w
 	}
 
 	exit := make(chan bool, 2)
 	go func() {
-		if _, err := io.Copy(os.Stdout, stdout); err != nil {
+		if _, err := io.Copy(outw, stdout); err != nil {
 			panic(err)
 		}
 		exit <- true
 	}()
 	go func() {
-		if _, err := io.Copy(os.Stderr, stderr); err != nil {
+		if _, err := io.Copy(errw, stderr); err != nil {
 			panic(err)
 		}
 		exit <- true
@@ -67,8 +120,10 @@ func ExecCopyOutput(app string, args []string) error {
 
 	<-exit
 	<-exit
-	if err := cmd.Wait(); err != nil {
-		return fmt.Errorf("wait failed: %s", err)
+	err = wait()
+	trace(ctx, line, start, err)
+	if err != nil {
+		return fmt.Errorf("wait failed: %w", err)
 	}
 
 	return nil
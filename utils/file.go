@@ -10,18 +10,24 @@ import (
 	"path/filepath"
 
 	"github.com/ernestokarim/cb/config"
+	"github.com/ernestokarim/cb/events"
 )
 
 // WriteFile creates the needed directory structure to write the whole content
 // string inside a file with the specified path.
 func WriteFile(path, content string) error {
+	if dryRun("write %s", path) {
+		return nil
+	}
+
 	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
-		return fmt.Errorf("cannot prepare the folders: %s", err)
+		return fmt.Errorf("cannot prepare the folders: %w", err)
 	}
 
 	if err := ioutil.WriteFile(path, []byte(content), 0755); err != nil {
-		return fmt.Errorf("write file failed: %s", err)
+		return fmt.Errorf("write file failed: %w", err)
 	}
+	fileEvent("write", path)
 
 	return nil
 }
@@ -29,34 +35,137 @@ func WriteFile(path, content string) error {
 // CopyFile creates a new destPath file copying manually all the contents
 // of the srcPath original file.
 func CopyFile(srcPath, destPath string) error {
+	if dryRun("copy %s -> %s", srcPath, destPath) {
+		return nil
+	}
 	if *config.Verbose {
 		log.Printf("copy file `%s`\n", srcPath)
 	}
 
 	dest, err := os.Create(destPath)
 	if err != nil {
-		return fmt.Errorf("cannot create dest file: %s", err)
+		return fmt.Errorf("cannot create dest file: %w", err)
 	}
 	defer dest.Close()
 
 	src, err := os.Open(srcPath)
 	if err != nil {
-		return fmt.Errorf("cannot open source file: %s", err)
+		return fmt.Errorf("cannot open source file: %w", err)
 	}
 	defer src.Close()
 
 	if _, err := io.Copy(dest, src); err != nil {
-		return fmt.Errorf("copy failed: %s", err)
+		return fmt.Errorf("copy failed: %w", err)
+	}
+	fileEvent("copy", destPath)
+
+	return nil
+}
+
+// CreateFile creates the needed directory structure and opens a new
+// file for writing, truncating it if it already exists.
+func CreateFile(path string) (io.WriteCloser, error) {
+	if dryRun("write %s", path) {
+		return nopCloser{ioutil.Discard}, nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("cannot prepare the folders: %w", err)
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("create file failed: %w", err)
+	}
+	fileEvent("write", path)
+	return f, nil
+}
+
+// AppendFile opens an existing file to write new content at the end of it.
+func AppendFile(path string) (io.WriteCloser, error) {
+	if dryRun("append %s", path) {
+		return nopCloser{ioutil.Discard}, nil
+	}
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		return nil, fmt.Errorf("open file failed: %w", err)
+	}
+	fileEvent("append", path)
+	return f, nil
+}
+
+type nopCloser struct {
+	io.Writer
+}
+
+func (nopCloser) Close() error { return nil }
+
+// MkdirAll creates a folder and all its parents if needed.
+func MkdirAll(path string) error {
+	if dryRun("mkdir %s", path) {
+		return nil
+	}
+	return os.MkdirAll(path, 0755)
+}
+
+// Chmod changes the permissions of a file or folder.
+func Chmod(path string, mode os.FileMode) error {
+	if dryRun("chmod %s %s", mode, path) {
+		return nil
+	}
+	return os.Chmod(path, mode)
+}
+
+// Rename moves a file or folder to a new path.
+func Rename(oldpath, newpath string) error {
+	if dryRun("rename %s -> %s", oldpath, newpath) {
+		return nil
+	}
+	if err := os.Rename(oldpath, newpath); err != nil {
+		return err
 	}
+	fileEvent("rename", newpath)
+	return nil
+}
 
+// Remove deletes a file or an empty folder.
+func Remove(path string) error {
+	if dryRun("remove %s", path) {
+		return nil
+	}
+	if err := os.Remove(path); err != nil {
+		return err
+	}
+	fileEvent("remove", path)
 	return nil
 }
 
+// RemoveAll deletes a path and all the children it contains.
+func RemoveAll(path string) error {
+	if dryRun("remove %s", path) {
+		return nil
+	}
+	if err := os.RemoveAll(path); err != nil {
+		return err
+	}
+	fileEvent("remove", path)
+	return nil
+}
+
+func fileEvent(op, path string) {
+	events.Emit(&events.Event{
+		Type:      events.File,
+		Operation: op,
+		Path:      path,
+	})
+}
+
 // ReadLines read a file line by line using a buffer and return the list.
 func ReadLines(path string) ([]string, error) {
 	f, err := os.Open(path)
 	if err != nil {
-		return nil, fmt.Errorf("cannot open file: %s", err)
+		return nil, fmt.Errorf("cannot open file: %w", err)
 	}
 	defer f.Close()
 
@@ -68,7 +177,7 @@ func ReadLines(path string) ([]string, error) {
 			break
 		}
 		if err != nil {
-			return nil, fmt.Errorf("read file line failed: %s", err)
+			return nil, fmt.Errorf("read file line failed: %w", err)
 		}
 
 		lines = append(lines, line)
@@ -0,0 +1,54 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	"github.com/ernestokarim/cb/colors"
+	"github.com/ernestokarim/cb/events"
+)
+
+type outputKey struct{}
+
+// WithOutput returns a copy of ctx whose logs and commands output will be
+// written to w instead of the standard output. It lets the queue buffer
+// the output of the tasks running at the same time.
+func WithOutput(ctx context.Context, w io.Writer) context.Context {
+	return context.WithValue(ctx, outputKey{}, w)
+}
+
+// Output returns the writer where the task associated with ctx should
+// print its messages. The standard output is reserved for the events
+// when using the JSON format.
+func Output(ctx context.Context) io.Writer {
+	if w, ok := ctx.Value(outputKey{}).(io.Writer); ok {
+		return w
+	}
+	if events.Enabled() {
+		return os.Stderr
+	}
+	return os.Stdout
+}
+
+// Logf writes a new log line to the output of the task.
+func Logf(ctx context.Context, format string, a ...interface{}) {
+	w, ok := ctx.Value(outputKey{}).(io.Writer)
+	if !ok {
+		log.Printf(format, a...)
+		return
+	}
+	log.New(w, "", log.Flags()).Printf(format, a...)
+}
+
+// Warningf logs a warning of the task and emits the event.
+func Warningf(ctx context.Context, format string, a ...interface{}) {
+	msg := fmt.Sprintf(format, a...)
+	Logf(ctx, "%s%s%s\n", colors.Yellow, msg, colors.Reset)
+	events.Emit(&events.Event{
+		Type:    events.Warning,
+		Message: msg,
+	})
+}
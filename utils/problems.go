@@ -0,0 +1,42 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+)
+
+// Problem is an error found by a tool in a source file.
+type Problem struct {
+	Tool    string `json:"tool"`
+	File    string `json:"file,omitempty"`
+	Line    int    `json:"line,omitempty"`
+	Column  int    `json:"column,omitempty"`
+	Message string `json:"message"`
+}
+
+func (p *Problem) String() string {
+	switch {
+	case p.File == "":
+		return p.Message
+	case p.Line == 0:
+		return fmt.Sprintf("%s: %s", p.File, p.Message)
+	case p.Column == 0:
+		return fmt.Sprintf("%s:%d: %s", p.File, p.Line, p.Message)
+	}
+	return fmt.Sprintf("%s:%d:%d: %s", p.File, p.Line, p.Column, p.Message)
+}
+
+type problemsKey struct{}
+
+// WithProblems returns a copy of ctx that reports to f the problems found
+// by the tools run with it. It lets the server show them in the browser.
+func WithProblems(ctx context.Context, f func(p *Problem)) context.Context {
+	return context.WithValue(ctx, problemsKey{}, f)
+}
+
+// ReportProblem sends the problem to the function registered in ctx, if any.
+func ReportProblem(ctx context.Context, p *Problem) {
+	if f, ok := ctx.Value(problemsKey{}).(func(p *Problem)); ok {
+		f(p)
+	}
+}
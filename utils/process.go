@@ -0,0 +1,43 @@
+package utils
+
+import (
+	"context"
+	"os/exec"
+	"time"
+)
+
+// Time the child processes have to exit after a SIGTERM, before
+// killing them.
+const killTimeout = 5 * time.Second
+
+// startProcess runs cmd in its own process group, returning the function
+// that waits for it to finish. When ctx is cancelled the whole group
+// receives a SIGTERM, so the children of the command (e.g. the java
+// process launched by a script) are stopped too.
+func startProcess(ctx context.Context, cmd *exec.Cmd) (func() error, error) {
+	setProcessGroup(cmd)
+
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+
+	done := make(chan bool)
+	go func() {
+		select {
+		case <-done:
+		case <-ctx.Done():
+			terminateGroup(cmd.Process, false)
+			select {
+			case <-done:
+			case <-time.After(killTimeout):
+				terminateGroup(cmd.Process, true)
+			}
+		}
+	}()
+
+	wait := func() error {
+		defer close(done)
+		return cmd.Wait()
+	}
+	return wait, nil
+}
@@ -0,0 +1,17 @@
+package utils
+
+import (
+	"os"
+	"os/exec"
+)
+
+// setProcessGroup does nothing in Windows, the children of the command
+// are not stopped with it.
+func setProcessGroup(cmd *exec.Cmd) {
+}
+
+// terminateGroup kills the process; there are no signals in Windows to
+// ask it to exit.
+func terminateGroup(p *os.Process, kill bool) {
+	p.Kill()
+}
@@ -0,0 +1,28 @@
+package utils
+
+import (
+	"context"
+	"time"
+)
+
+// Span is the time spent running a child process.
+type Span struct {
+	Name     string
+	Start    time.Time
+	Duration time.Duration
+	Err      error
+}
+
+type traceKey struct{}
+
+// WithTrace returns a copy of ctx that reports to f each command executed
+// with it. It lets the queue profile the tasks.
+func WithTrace(ctx context.Context, f func(span Span)) context.Context {
+	return context.WithValue(ctx, traceKey{}, f)
+}
+
+func trace(ctx context.Context, name string, start time.Time, err error) {
+	if f, ok := ctx.Value(traceKey{}).(func(span Span)); ok {
+		f(Span{name, start, time.Since(start), err})
+	}
+}
@@ -4,90 +4,281 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
 )
 
 // WalkFunc is the type all callbacks should implement when walking a path.
 type WalkFunc func(path string, info os.FileInfo) error
 
-// Walker represents a list of folders & files. It can match the
-// path, the name or the ext. If any of them is '*' it will
-// be matched against anything. Recursive is enabled when a '**'
-// appears as the last element of the path.
+// Walker represents a list of folders & files matched by glob patterns.
+// Each segment of a pattern is matched like filepath.Match (`*`, `?` and
+// character classes like `[a-z]` or `[!0-9]`), plus:
 //
-// Examples of paths:
-//   /path/** /*.*
-//   /path/*         -> short for -> /path/*.*
-//   /path/*.jpg
-//   /path/config.*
-//   /path/**        -> short for -> /path/**/*.*
+//	**            matches any number of folders, anywhere in the path
+//	{a,b}         matches any of the alternatives; they can be nested
+//	!pattern      excludes the matching files & folders (and their content)
+//	path          without wildcards matches the file, or the folder and
+//	              everything inside it
 //
+// Examples of patterns:
+//
+//	app/styles/*.scss
+//	app/styles/{admin,public}/**/*.scss
+//	app/**
+//	!**/vendor/**
 type Walker struct {
-	Path, Name, Ext string
-	Recursive       bool
+	includes [][]string
+	excludes [][]string
 }
 
-// NewWalker creates a new walker for the dir path (see Walker docs for formats).
-func NewWalker(dir string) *Walker {
+// NewWalker creates a new walker for the patterns (see Walker docs for
+// formats). A file is matched if it matches any of them and none of the
+// negated ones.
+func NewWalker(patterns ...string) *Walker {
 	w := &Walker{}
+	for _, pattern := range patterns {
+		exclude := strings.HasPrefix(pattern, "!")
+		if exclude {
+			pattern = pattern[1:]
+		}
 
-	w.Ext = filepath.Ext(dir)
-	if w.Ext == "" || w.Ext == ".*" {
-		w.Ext = "*"
+		for _, expanded := range expandBraces(pattern) {
+			segments := splitPath(expanded)
+			for i, segment := range segments {
+				// Negated classes of the shells, filepath.Match uses `[^...]`
+				segments[i] = strings.Replace(segment, "[!", "[^", -1)
+			}
+			if !hasMeta(expanded) {
+				segments = append(segments, "**")
+			}
+			if exclude {
+				w.excludes = append(w.excludes, segments)
+			} else {
+				w.includes = append(w.includes, segments)
+			}
+		}
 	}
+	return w
+}
 
-	w.Name = filepath.Base(dir)
-	if w.Name == "**" {
-		w.Name = "*"
-		w.Recursive = true
-	} else {
-		w.Name = w.Name[:len(w.Name)-len(w.Ext)]
+// JoinPatterns prefixes the patterns with the dir, keeping the negations.
+func JoinPatterns(dir string, patterns []string) []string {
+	result := []string{}
+	for _, pattern := range patterns {
+		if strings.HasPrefix(pattern, "!") {
+			result = append(result, "!"+filepath.Join(dir, pattern[1:]))
+		} else {
+			result = append(result, filepath.Join(dir, pattern))
+		}
 	}
+	return result
+}
 
-	w.Path = filepath.Dir(dir)
-	if d, f := filepath.Split(w.Path); f == "**" {
-		w.Path = d
-		w.Recursive = true
+// Roots returns the folders where the walker looks for files, without
+// wildcards, and if the files can be in their subfolders too.
+func (w *Walker) Roots() map[string]bool {
+	roots := map[string]bool{}
+	for _, pattern := range w.includes {
+		root, rest := splitRoot(pattern)
+		recursive := len(rest) > 1
+		for _, segment := range rest {
+			recursive = recursive || segment == "**"
+		}
+		roots[root] = roots[root] || recursive
 	}
+	return roots
+}
 
-	return w
+// Match returns true if the walker would visit the file in its walk.
+func (w *Walker) Match(path string) bool {
+	segments := splitPath(path)
+	if w.excluded(segments) {
+		return false
+	}
+	for _, pattern := range w.includes {
+		if matchSegments(pattern, segments) {
+			return true
+		}
+	}
+	return false
 }
 
 // Walk calls the walkFn callback for each file or folder that
-// matches the walker path.
+// matches the walker patterns, sorted by path. Missing folders
+// don't match anything.
 func (w *Walker) Walk(walkFn WalkFunc) error {
-	fn := func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return fmt.Errorf("walk failed: %s", err)
+	matches := map[string]os.FileInfo{}
+	for root := range w.Roots() {
+		fn := func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				if path == root && os.IsNotExist(err) {
+					return nil
+				}
+				return fmt.Errorf("walk failed: %w", err)
+			}
+
+			segments := splitPath(path)
+			if w.excluded(segments) {
+				if info.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+
+			descend := false
+			for _, pattern := range w.includes {
+				if len(segments) > 0 && matchSegments(pattern, segments) {
+					matches[path] = info
+				}
+				descend = descend || matchPrefix(pattern, segments)
+			}
+			if info.IsDir() && !descend {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if err := filepath.Walk(root, fn); err != nil {
+			return fmt.Errorf("walk nodes failed: %w", err)
 		}
+	}
 
-		ext := filepath.Ext(path)
-		name := filepath.Base(path)
-		name = name[:len(name)-len(ext)]
+	paths := []string{}
+	for path := range matches {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		if err := walkFn(path, matches[path]); err != nil {
+			return fmt.Errorf("walkfn failed: %w", err)
+		}
+	}
+	return nil
+}
 
-		// Checks
-		check := true
-		if w.Name != "*" {
-			check = (name == w.Name)
+func (w *Walker) excluded(segments []string) bool {
+	for _, pattern := range w.excludes {
+		if matchSegments(pattern, segments) {
+			return true
 		}
-		if check && w.Ext != "*" {
-			check = (ext == w.Ext)
+	}
+	return false
+}
+
+// matchSegments returns true if the path matches the whole pattern.
+func matchSegments(pattern, segments []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(segments); i++ {
+				if matchSegments(pattern[1:], segments[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(segments) == 0 {
+			return false
 		}
+		if ok, _ := filepath.Match(pattern[0], segments[0]); !ok {
+			return false
+		}
+		pattern, segments = pattern[1:], segments[1:]
+	}
+	return len(segments) == 0
+}
 
-		// Walk function call
-		if check {
-			if err := walkFn(path, info); err != nil {
-				return fmt.Errorf("walkfn failed: %s", err)
+// matchPrefix returns true if the files inside the folder could match
+// the pattern.
+func matchPrefix(pattern, segments []string) bool {
+	for len(segments) > 0 {
+		if len(pattern) == 0 {
+			return false
+		}
+		if pattern[0] == "**" {
+			return true
+		}
+		if ok, _ := filepath.Match(pattern[0], segments[0]); !ok {
+			return false
+		}
+		pattern, segments = pattern[1:], segments[1:]
+	}
+	return len(pattern) > 0
+}
+
+// splitRoot separates the first segments of the pattern without
+// wildcards from the rest of them.
+func splitRoot(pattern []string) (string, []string) {
+	i := 0
+	for i < len(pattern) && !hasMeta(pattern[i]) {
+		i++
+	}
+	if i == 0 {
+		return ".", pattern
+	}
+	root := strings.Join(pattern[:i], string(filepath.Separator))
+	if root == "" {
+		root = string(filepath.Separator)
+	}
+	return root, pattern[i:]
+}
+
+func splitPath(path string) []string {
+	path = filepath.Clean(path)
+	if path == "." {
+		return []string{}
+	}
+	return strings.Split(path, string(filepath.Separator))
+}
+
+func hasMeta(pattern string) bool {
+	return strings.ContainsAny(pattern, `*?[{\`)
+}
+
+// expandBraces returns the patterns resulting of replacing the first
+// `{a,b}` set (and the ones nested or after it) with each alternative.
+func expandBraces(pattern string) []string {
+	start, end, depth := -1, -1, 0
+	for i, c := range pattern {
+		if c == '{' {
+			if depth == 0 {
+				start = i
+			}
+			depth++
+		} else if c == '}' && depth > 0 {
+			depth--
+			if depth == 0 {
+				end = i
+				break
 			}
 		}
+	}
+	if start == -1 || end == -1 {
+		return []string{pattern}
+	}
 
-		// Recursive scanning ?
-		if !w.Recursive && info.IsDir() && w.Path != path {
-			return filepath.SkipDir
+	// Split the alternatives at the top level commas
+	alternatives := []string{}
+	depth = 0
+	last := start + 1
+	for i := start + 1; i < end; i++ {
+		switch pattern[i] {
+		case '{':
+			depth++
+		case '}':
+			depth--
+		case ',':
+			if depth == 0 {
+				alternatives = append(alternatives, pattern[last:i])
+				last = i + 1
+			}
 		}
-		return nil
 	}
-	if err := filepath.Walk(w.Path, fn); err != nil {
-		return fmt.Errorf("walk nodes failed: %s", err)
+	alternatives = append(alternatives, pattern[last:end])
+
+	result := []string{}
+	for _, alternative := range alternatives {
+		expanded := pattern[:start] + alternative + pattern[end+1:]
+		result = append(result, expandBraces(expanded)...)
 	}
-	return nil
+	return result
 }
@@ -0,0 +1,158 @@
+package utils
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestExpandBraces(t *testing.T) {
+	tests := []struct {
+		pattern string
+		want    []string
+	}{
+		{"app/*.js", []string{"app/*.js"}},
+		{"app/{a,b}.js", []string{"app/a.js", "app/b.js"}},
+		{"{a,b}/{c,d}", []string{"a/c", "a/d", "b/c", "b/d"}},
+		{"app/{a,b{c,d}}/x", []string{"app/a/x", "app/bc/x", "app/bd/x"}},
+		{"app/{a,}.js", []string{"app/a.js", "app/.js"}},
+		{"app/{a.js", []string{"app/{a.js"}},
+	}
+	for _, test := range tests {
+		if got := expandBraces(test.pattern); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("expandBraces(%q) = %q, want %q", test.pattern, got, test.want)
+		}
+	}
+}
+
+func TestWalkerMatch(t *testing.T) {
+	tests := []struct {
+		patterns []string
+		path     string
+		want     bool
+	}{
+		{[]string{"app/styles/*.scss"}, "app/styles/main.scss", true},
+		{[]string{"app/styles/*.scss"}, "app/styles/admin/main.scss", false},
+		{[]string{"app/styles/*.scss"}, "app/styles/main.css", false},
+		{[]string{"app/**/*.scss"}, "app/main.scss", true},
+		{[]string{"app/**/*.scss"}, "app/a/b/c/main.scss", true},
+		{[]string{"**/vendor/*.js"}, "app/lib/vendor/a.js", true},
+		{[]string{"**/vendor/*.js"}, "vendor/a.js", true},
+		{[]string{"app/**"}, "app/a/b.js", true},
+		{[]string{"app/styles/{admin,public}/*.scss"}, "app/styles/public/a.scss", true},
+		{[]string{"app/styles/{admin,public}/*.scss"}, "app/styles/private/a.scss", false},
+		{[]string{"app/file[0-9].js"}, "app/file1.js", true},
+		{[]string{"app/file[!0-9].js"}, "app/file1.js", false},
+		{[]string{"app/file[!0-9].js"}, "app/filea.js", true},
+		{[]string{"app/file?.js"}, "app/file1.js", true},
+
+		// Paths without wildcards match everything inside them
+		{[]string{"app/scripts"}, "app/scripts", true},
+		{[]string{"app/scripts"}, "app/scripts/a/b.js", true},
+		{[]string{"app/scripts"}, "app/scripts2/b.js", false},
+
+		// Negations
+		{[]string{"app/**/*.js", "!**/vendor/**"}, "app/a.js", true},
+		{[]string{"app/**/*.js", "!**/vendor/**"}, "app/vendor/a.js", false},
+		{[]string{"app/**/*.js", "!app/vendor"}, "app/vendor/x/a.js", false},
+		{[]string{"app/**/*.js", "!app/*.min.js"}, "app/a.min.js", false},
+		{[]string{"!app/**"}, "app/a.js", false},
+	}
+	for _, test := range tests {
+		w := NewWalker(test.patterns...)
+		if got := w.Match(filepath.FromSlash(test.path)); got != test.want {
+			t.Errorf("%v Match(%s) = %v, want %v", test.patterns, test.path, got, test.want)
+		}
+	}
+}
+
+func TestWalkerRoots(t *testing.T) {
+	tests := []struct {
+		patterns []string
+		want     map[string]bool
+	}{
+		{[]string{"app/styles/*.scss"}, map[string]bool{"app/styles": false}},
+		{[]string{"app/**/*.scss"}, map[string]bool{"app": true}},
+		{[]string{"*.js"}, map[string]bool{".": false}},
+		{[]string{"app/*/x.js", "app/*.js"}, map[string]bool{"app": true}},
+		{[]string{"app/scripts", "!app/scripts/vendor"}, map[string]bool{"app/scripts": true}},
+	}
+	for _, test := range tests {
+		want := map[string]bool{}
+		for root, recursive := range test.want {
+			want[filepath.FromSlash(root)] = recursive
+		}
+		if got := NewWalker(test.patterns...).Roots(); !reflect.DeepEqual(got, want) {
+			t.Errorf("%v Roots() = %v, want %v", test.patterns, got, want)
+		}
+	}
+}
+
+func TestWalk(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cb-walk")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	files := []string{
+		"app/index.html",
+		"app/scripts/app.js",
+		"app/scripts/app.min.js",
+		"app/scripts/admin/users.js",
+		"app/scripts/vendor/jquery.js",
+		"app/styles/main.scss",
+	}
+	for _, file := range files {
+		path := filepath.Join(dir, filepath.FromSlash(file))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		patterns []string
+		want     []string
+	}{
+		{
+			[]string{"app/scripts/**/*.js", "!**/vendor/**", "!**/*.min.js"},
+			[]string{"app/scripts/admin/users.js", "app/scripts/app.js"},
+		},
+		{
+			[]string{"app/{styles,missing}/*.scss", "app/*.html"},
+			[]string{"app/index.html", "app/styles/main.scss"},
+		},
+		{
+			[]string{"app/scripts", "!app/scripts/{admin,vendor}"},
+			[]string{"app/scripts", "app/scripts/app.js", "app/scripts/app.min.js"},
+		},
+		{
+			[]string{"missing/**/*.js"},
+			[]string{},
+		},
+	}
+	for _, test := range tests {
+		got := []string{}
+		w := NewWalker(JoinPatterns(dir, test.patterns)...)
+		err := w.Walk(func(path string, info os.FileInfo) error {
+			rel, err := filepath.Rel(dir, path)
+			if err != nil {
+				return err
+			}
+			got = append(got, filepath.ToSlash(rel))
+			return nil
+		})
+		if err != nil {
+			t.Errorf("%v Walk() failed: %s", test.patterns, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%v Walk() = %v, want %v", test.patterns, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,154 @@
+package watcher
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"unsafe"
+)
+
+const inotifyMask = syscall.IN_CREATE | syscall.IN_CLOSE_WRITE | syscall.IN_MODIFY |
+	syscall.IN_DELETE | syscall.IN_MOVED_FROM | syscall.IN_MOVED_TO | syscall.IN_ATTRIB
+
+// inotify receives the changes of the folders from the kernel. Recursive
+// roots watch all their subfolders, including the ones created later.
+type inotify struct {
+	fd      int
+	f       *os.File
+	changes chan string
+	done    chan struct{}
+
+	mutex     sync.Mutex
+	dirs      map[int32]string
+	recursive map[int32]bool
+}
+
+func newNotifier(roots map[string]bool) (notifier, error) {
+	fd, err := syscall.InotifyInit1(syscall.IN_CLOEXEC | syscall.IN_NONBLOCK)
+	if err != nil {
+		return nil, fmt.Errorf("inotify init failed: %w", err)
+	}
+	n := &inotify{
+		fd:        fd,
+		f:         os.NewFile(uintptr(fd), "inotify"),
+		changes:   make(chan string),
+		done:      make(chan struct{}),
+		dirs:      map[int32]string{},
+		recursive: map[int32]bool{},
+	}
+
+	for root, recursive := range roots {
+		if err := n.add(root, recursive); err != nil {
+			n.Close()
+			return nil, err
+		}
+	}
+
+	go n.read()
+	return n, nil
+}
+
+func (n *inotify) Changes() <-chan string {
+	return n.changes
+}
+
+func (n *inotify) Close() error {
+	close(n.done)
+	return n.f.Close()
+}
+
+// add watches the folder, and all the ones inside it if recursive.
+// Missing folders are ignored; files are watched through their folder.
+func (n *inotify) add(dir string, recursive bool) error {
+	if info, err := os.Stat(dir); err == nil && !info.IsDir() {
+		dir, recursive = filepath.Dir(dir), false
+	}
+
+	fn := func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return fmt.Errorf("walk failed: %w", err)
+		}
+		if !info.IsDir() {
+			return nil
+		}
+		if path != dir && (!recursive || matchIgnore(defaultIgnores, path)) {
+			return filepath.SkipDir
+		}
+
+		wd, err := syscall.InotifyAddWatch(n.fd, path, inotifyMask)
+		if err != nil {
+			return fmt.Errorf("watch %s failed: %w", path, err)
+		}
+		n.mutex.Lock()
+		n.dirs[int32(wd)] = path
+		n.recursive[int32(wd)] = recursive
+		n.mutex.Unlock()
+		return nil
+	}
+	return filepath.Walk(dir, fn)
+}
+
+func (n *inotify) read() {
+	defer close(n.changes)
+
+	buf := make([]byte, 64*(syscall.SizeofInotifyEvent+syscall.NAME_MAX+1))
+	for {
+		size, err := n.f.Read(buf)
+		if err != nil {
+			return
+		}
+
+		for offset := 0; offset+syscall.SizeofInotifyEvent <= size; {
+			event := (*syscall.InotifyEvent)(unsafe.Pointer(&buf[offset]))
+			start := offset + syscall.SizeofInotifyEvent
+			name := strings.TrimRight(string(buf[start:start+int(event.Len)]), "\x00")
+			offset = start + int(event.Len)
+
+			n.handle(event, name)
+		}
+	}
+}
+
+func (n *inotify) handle(event *syscall.InotifyEvent, name string) {
+	if event.Mask&syscall.IN_Q_OVERFLOW != 0 {
+		// Some events were lost, check everything
+		n.send("")
+		return
+	}
+
+	n.mutex.Lock()
+	dir, ok := n.dirs[event.Wd]
+	recursive := n.recursive[event.Wd]
+	if event.Mask&syscall.IN_IGNORED != 0 {
+		delete(n.dirs, event.Wd)
+		delete(n.recursive, event.Wd)
+	}
+	n.mutex.Unlock()
+	if !ok || name == "" {
+		return
+	}
+
+	path := filepath.Join(dir, name)
+	created := event.Mask&(syscall.IN_CREATE|syscall.IN_MOVED_TO) != 0
+	if event.Mask&syscall.IN_ISDIR != 0 && created && recursive {
+		// The files could be written before watching the new folder
+		if err := n.add(path, true); err == nil {
+			n.send("")
+			return
+		}
+	}
+	n.send(path)
+}
+
+func (n *inotify) send(path string) {
+	select {
+	case n.changes <- path:
+	case <-n.done:
+	}
+}
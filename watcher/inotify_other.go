@@ -0,0 +1,11 @@
+//go:build !linux
+
+package watcher
+
+import (
+	"fmt"
+)
+
+func newNotifier(roots map[string]bool) (notifier, error) {
+	return nil, fmt.Errorf("not supported in this system")
+}
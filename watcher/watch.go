@@ -0,0 +1,149 @@
+package watcher
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/ernestokarim/cb/colors"
+)
+
+const (
+	// Time without new events before checking the changes, so a save that
+	// writes several files runs the tasks only once.
+	debounce = 200 * time.Millisecond
+
+	// Interval of the checks when the system notifications are not available.
+	pollInterval = time.Second
+)
+
+// notifier reports the paths that change in the watched folders. An empty
+// path means that all of them should be checked.
+type notifier interface {
+	Changes() <-chan string
+	Close() error
+}
+
+// Watch waits for changes in the watched files, calling run with the key
+// of each modified set, in the order they were registered. It uses the
+// notifications of the system if available, scanning the files every
+// second otherwise. It returns when ctx is cancelled.
+//
+// All the keys are checked when starting, running the ones whose files
+// changed while cb was stopped.
+func Watch(ctx context.Context, run func(key string) error) error {
+	n, err := newNotifier(roots())
+	if err != nil {
+		log.Printf("%sfile notifications not available, polling the files: %s%s\n",
+			colors.Yellow, err, colors.Reset)
+		n = newPoller()
+	}
+	defer n.Close()
+
+	pending := map[string]bool{}
+	for _, key := range registered() {
+		pending[key] = true
+	}
+	timer := time.NewTimer(debounce)
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+
+		case path, ok := <-n.Changes():
+			if !ok {
+				return fmt.Errorf("file notifications stopped")
+			}
+			matched := matchKeys(path)
+			if path == "" {
+				matched = registered()
+			}
+			for _, key := range matched {
+				pending[key] = true
+			}
+			if len(pending) > 0 {
+				// Drain a pending tick, or it would check the changes
+				// before the debounce time
+				if !timer.Stop() {
+					select {
+					case <-timer.C:
+					default:
+					}
+				}
+				timer.Reset(debounce)
+			}
+
+		case <-timer.C:
+			for _, key := range registered() {
+				if !pending[key] {
+					continue
+				}
+				refresh(key, run)
+			}
+			pending = map[string]bool{}
+		}
+	}
+}
+
+// refresh runs the key if its files changed, reporting the result.
+func refresh(key string, run func(key string) error) {
+	start := time.Now()
+	modified, err := Refresh(key, func(changes *Changes) error {
+		log.Printf("%s[watch] %s, running %s%s\n", colors.Cyan, changes, key, colors.Reset)
+		return run(key)
+	})
+	if err != nil {
+		log.Printf("%s[watch] %s failed: %s%s\n", colors.Red, key, err, colors.Reset)
+		return
+	}
+	if modified {
+		log.Printf("%s[watch] %s finished in %.3f seconds%s\n", colors.Green, key,
+			time.Since(start).Seconds(), colors.Reset)
+	}
+}
+
+func registered() []string {
+	walkersMutex.Lock()
+	defer walkersMutex.Unlock()
+	return append([]string{}, keys...)
+}
+
+// poller checks all the files periodically.
+type poller struct {
+	changes chan string
+	done    chan struct{}
+}
+
+func newPoller() *poller {
+	p := &poller{
+		changes: make(chan string),
+		done:    make(chan struct{}),
+	}
+	go func() {
+		ticker := time.NewTicker(pollInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-p.done:
+				return
+			case <-ticker.C:
+				select {
+				case p.changes <- "":
+				case <-p.done:
+					return
+				}
+			}
+		}
+	}()
+	return p
+}
+
+func (p *poller) Changes() <-chan string {
+	return p.changes
+}
+
+func (p *poller) Close() error {
+	close(p.done)
+	return nil
+}
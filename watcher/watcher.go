@@ -1,9 +1,14 @@
 package watcher
 
 import (
+	"crypto/sha1"
+	"encoding/hex"
 	"fmt"
 	"log"
 	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
 	"sync"
 
 	"github.com/ernestokarim/cb/cache"
@@ -11,69 +16,304 @@ import (
 	"github.com/ernestokarim/cb/utils"
 )
 
+// Files never watched: VCS folders, dependencies & the temp files
+// of the editors.
+var defaultIgnores = []string{
+	".git", ".svn", ".hg", "node_modules",
+	".*.swp", ".*.swx", "*~", ".#*", "#*#", "4913",
+}
+
 var (
 	walkers      = map[string][]*utils.Walker{}
+	ignores      = map[string][]string{}
+	keys         = []string{}
 	walkersMutex = &sync.Mutex{}
+
+	// Only one refresh runs at a time
+	refreshMutex = &sync.Mutex{}
+
+	// Changes that triggered the running refresh of each key
+	current      = map[string]*Changes{}
+	currentMutex = &sync.Mutex{}
+
+	// Keys without files from a previous session
+	fresh = map[string]bool{}
+
+	// Files of each key the last time they were checked
+	caches      = map[string]*cache.Cache{}
+	cachesMutex = &sync.Mutex{}
 )
 
+var cacheNameRe = regexp.MustCompile(`[^a-z0-9-]+`)
+
+// Changes lists the files of a key that changed since the last check.
+// A renamed file appears as removed with its old name and as added with
+// the new one.
+type Changes struct {
+	Added, Changed, Removed []string
+}
+
+// Empty returns true if no file changed.
+func (ch *Changes) Empty() bool {
+	return len(ch.Added) == 0 && len(ch.Changed) == 0 && len(ch.Removed) == 0
+}
+
+func (ch *Changes) String() string {
+	return fmt.Sprintf("%d added, %d changed, %d removed", len(ch.Added),
+		len(ch.Changed), len(ch.Removed))
+}
+
 // Dirs add a new set of directories &files to the watched ones under
-// the key name identification.
+// the key name identification. They are patterns of a utils.Walker,
+// so the negated ones exclude files of the rest of them.
+//
+// The files of the previous session are kept, so the first check reports
+// the changes made while cb was not running.
 func Dirs(dirs []string, key string) error {
 	walkersMutex.Lock()
-	defer walkersMutex.Unlock()
-
-	for _, dir := range dirs {
-		w := utils.NewWalker(dir)
-		walkers[key] = append(walkers[key], w)
-		if *config.Verbose {
+	if walkers[key] == nil {
+		empty, err := keyCache(key).Empty()
+		if err != nil {
+			walkersMutex.Unlock()
+			return fmt.Errorf("load cache failed: %w", err)
+		}
+		keys = append(keys, key)
+		fresh[key] = empty
+	}
+	walkers[key] = append(walkers[key], utils.NewWalker(dirs...))
+	if *config.Verbose {
+		for _, dir := range dirs {
 			log.Printf("watching `%s`\n", dir)
 		}
 	}
+	first := fresh[key]
+	walkersMutex.Unlock()
 
-	// First check to store the initial times
-	if _, err := CheckModified(key); err != nil {
-		return fmt.Errorf("check cache failed: %s", err)
+	// First check to store the initial files if there is no previous session
+	if first {
+		if _, err := CheckModified(key); err != nil {
+			return fmt.Errorf("check cache failed: %w", err)
+		}
+		if err := keyCache(key).Save(); err != nil {
+			return fmt.Errorf("save cache failed: %w", err)
+		}
 	}
 
 	return nil
 }
 
+// Ignore excludes the files matching the patterns from the ones watched
+// under the key. Patterns are matched against the name of the files and
+// the folders of their path, or the whole path (see filepath.Match).
+func Ignore(patterns []string, key string) error {
+	for _, pattern := range patterns {
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return fmt.Errorf("bad ignore pattern %s: %w", pattern, err)
+		}
+	}
+
+	walkersMutex.Lock()
+	defer walkersMutex.Unlock()
+	ignores[key] = append(ignores[key], patterns...)
+	return nil
+}
+
 // CheckModified returns true if the set of directories identified by the key
-// name is dirty (has new files or has been modified).
+// name is dirty (has new, modified or removed files).
 func CheckModified(key string) (bool, error) {
-	modified := false
-	for _, w := range walkers[key] {
-		m, err := checkWatcher(key, w)
-		if err != nil {
-			return false, fmt.Errorf("check walker failed: %s", err)
-		}
-		modified = modified || m
+	changes, err := CheckChanges(key)
+	if err != nil {
+		return false, err
 	}
-	return modified, nil
+	return !changes.Empty(), nil
 }
 
-func checkWatcher(key string, w *utils.Walker) (bool, error) {
-	if _, err := os.Stat(w.Path); err != nil {
-		if os.IsNotExist(err) {
-			return false, nil
+// CheckChanges returns the files of the key added, modified or removed
+// since the last check. Each key keeps its own list of files, so they
+// don't miss the changes of the files watched by several of them. The
+// list is saved to disk by Refresh, or at the end of the run.
+func CheckChanges(key string) (*Changes, error) {
+	walkersMutex.Lock()
+	ws := walkers[key]
+	walkersMutex.Unlock()
+
+	files := keyCache(key)
+	changes := &Changes{}
+	seen := map[string]bool{}
+	for _, w := range ws {
+		if err := checkWatcher(key, w, files, changes, seen); err != nil {
+			return nil, fmt.Errorf("check walker failed: %w", err)
+		}
+	}
+
+	// Files of the last check not present anymore
+	paths, err := files.Paths()
+	if err != nil {
+		return nil, fmt.Errorf("list cache failed: %w", err)
+	}
+	for _, path := range paths {
+		if seen[path] {
+			continue
 		}
-		return false, fmt.Errorf("stat failed: %s", err)
+		if err := files.Forget(path); err != nil {
+			return nil, fmt.Errorf("forget file failed: %w", err)
+		}
+		if isIgnored(key, path) || !matchWalkers(ws, path) {
+			continue
+		}
+		if *config.Verbose {
+			log.Printf("removed `%s` [%s]\n", path, key)
+		}
+		changes.Removed = append(changes.Removed, path)
 	}
+	return changes, nil
+}
+
+// Refresh calls f if the files of the key have changed since the last
+// check. Only one refresh runs at a time, so the tasks triggered by the
+// watcher & the ones requested by the server don't overlap.
+func Refresh(key string, f func(changes *Changes) error) (bool, error) {
+	refreshMutex.Lock()
+	defer refreshMutex.Unlock()
 
-	m := false
+	changes, err := CheckChanges(key)
+	if err != nil {
+		return false, err
+	}
+	if changes.Empty() {
+		return false, nil
+	}
+	if err := keyCache(key).Save(); err != nil {
+		return false, fmt.Errorf("save cache failed: %w", err)
+	}
+
+	currentMutex.Lock()
+	current[key] = changes
+	currentMutex.Unlock()
+	defer func() {
+		currentMutex.Lock()
+		delete(current, key)
+		currentMutex.Unlock()
+	}()
+	return true, f(changes)
+}
+
+// Changed returns the changes that triggered the running refresh of the
+// key, or nil if it's not being refreshed. Tasks can use it to process
+// only the files that changed.
+func Changed(key string) *Changes {
+	currentMutex.Lock()
+	defer currentMutex.Unlock()
+	return current[key]
+}
+
+func checkWatcher(key string, w *utils.Walker, files *cache.Cache, changes *Changes,
+	seen map[string]bool) error {
 	fn := func(path string, info os.FileInfo) error {
-		modified, err := cache.Modified(cache.KeyWatch, path)
+		if info.IsDir() || seen[path] || isIgnored(key, path) {
+			return nil
+		}
+		seen[path] = true
+
+		change, err := files.Compare(path)
 		if err != nil {
-			return fmt.Errorf("modified check failed: %s", err)
+			return fmt.Errorf("modified check failed: %w", err)
+		}
+		switch change {
+		case cache.Added:
+			changes.Added = append(changes.Added, path)
+		case cache.Changed:
+			changes.Changed = append(changes.Changed, path)
 		}
-		if modified && *config.Verbose {
+		if change != cache.Unchanged && *config.Verbose {
 			log.Printf("modified `%s` [%s]\n", path, key)
 		}
-		m = m || modified
 		return nil
 	}
 	if err := w.Walk(fn); err != nil {
-		return false, fmt.Errorf("walker execution failed: %s", err)
+		return fmt.Errorf("walker execution failed: %w", err)
+	}
+	return nil
+}
+
+// keyCache returns the cache of the files of the key, registering it
+// the first time.
+func keyCache(key string) *cache.Cache {
+	cachesMutex.Lock()
+	defer cachesMutex.Unlock()
+
+	if caches[key] == nil {
+		h := sha1.Sum([]byte(key))
+		name := cacheNameRe.ReplaceAllString(strings.ToLower(key), "-")
+		caches[key] = cache.Register(fmt.Sprintf("watch-%s-%s", name, hex.EncodeToString(h[:4])))
+	}
+	return caches[key]
+}
+
+func matchWalkers(ws []*utils.Walker, path string) bool {
+	for _, w := range ws {
+		if w.Match(path) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchKeys returns the keys that watch the path.
+func matchKeys(path string) []string {
+	walkersMutex.Lock()
+	defer walkersMutex.Unlock()
+
+	matched := []string{}
+	for _, key := range keys {
+		if isIgnoredLocked(key, path) {
+			continue
+		}
+		if matchWalkers(walkers[key], path) {
+			matched = append(matched, key)
+		}
+	}
+	return matched
+}
+
+// roots returns the folders that contain the watched files, and if their
+// subfolders should be watched too.
+func roots() map[string]bool {
+	walkersMutex.Lock()
+	defer walkersMutex.Unlock()
+
+	result := map[string]bool{}
+	for _, key := range keys {
+		for _, w := range walkers[key] {
+			for root, recursive := range w.Roots() {
+				result[root] = result[root] || recursive
+			}
+		}
+	}
+	return result
+}
+
+func isIgnored(key, path string) bool {
+	walkersMutex.Lock()
+	defer walkersMutex.Unlock()
+	return isIgnoredLocked(key, path)
+}
+
+func isIgnoredLocked(key, path string) bool {
+	return matchIgnore(defaultIgnores, path) || matchIgnore(ignores[key], path)
+}
+
+func matchIgnore(patterns []string, path string) bool {
+	path = filepath.Clean(path)
+	for _, pattern := range patterns {
+		if ok, _ := filepath.Match(pattern, path); ok {
+			return true
+		}
+		for p := path; p != "." && p != string(filepath.Separator); p = filepath.Dir(p) {
+			if ok, _ := filepath.Match(pattern, filepath.Base(p)); ok {
+				return true
+			}
+		}
 	}
-	return m, nil
+	return false
 }